@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// runExport 将已有的壁纸目录打包为 zip 或 tar.gz 归档
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		inputDir string
+		output   string
+		format   string
+		month    string
+		logLevel string
+		noTime   bool
+	)
+	fs.StringVar(&inputDir, "dir", "./bing_wallpapers", "要打包的壁纸目录")
+	fs.StringVar(&output, "o", "", "输出的归档文件路径 (如 2026-10.zip 或 2026-10.tar.gz)")
+	fs.StringVar(&format, "format", "", "归档格式 (zip, tar.gz)，默认根据输出文件扩展名推断")
+	fs.StringVar(&month, "month", "", "仅打包指定月份的文件 (YYYYMM)")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	if output == "" {
+		fmt.Printf("错误: 必须使用 -o 指定输出的归档文件\n")
+		return 1
+	}
+	if month != "" && (len(month) != 6 || strings.Trim(month, "0123456789") != "") {
+		fmt.Printf("错误: month 参数格式必须为 YYYYMM\n")
+		return 1
+	}
+
+	logger := newLogger(logLevel, noTime)
+
+	absInputDir, err := filepath.Abs(inputDir)
+	if err != nil {
+		fmt.Printf("错误: 无法获取绝对路径: %v\n", err)
+		return 1
+	}
+
+	archive, err := newArchiveStorage(output, format, absInputDir, logger)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	// 按月份过滤文件
+	var filter func(relPath string) bool
+	if month != "" {
+		filter = func(relPath string) bool {
+			date, ok := bingclient.DateFromFilename(relPath)
+			return ok && strings.HasPrefix(date, month)
+		}
+	}
+
+	count, err := archive.AddDirectory(absInputDir, filter)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("\n导出完成: 共 %d 个文件写入 %s\n", count, archive.ArchivePath)
+	return 0
+}
+
+// runImport 将归档中的文件解压到壁纸目录
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var (
+		input     string
+		outputDir string
+		format    string
+		overwrite bool
+		logLevel  string
+		noTime    bool
+	)
+	fs.StringVar(&input, "i", "", "要导入的归档文件路径")
+	fs.StringVar(&outputDir, "dir", "./bing_wallpapers", "壁纸保存目录")
+	fs.StringVar(&format, "format", "", "归档格式 (zip, tar.gz)，默认根据文件扩展名推断")
+	fs.BoolVar(&overwrite, "overwrite", false, "如果文件已存在则覆盖")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	if input == "" {
+		fmt.Printf("错误: 必须使用 -i 指定要导入的归档文件\n")
+		return 1
+	}
+	if !fileExists(input) {
+		fmt.Printf("错误: 归档文件不存在: %s\n", input)
+		return 1
+	}
+
+	logger := newLogger(logLevel, noTime)
+
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		fmt.Printf("错误: 无法获取绝对路径: %v\n", err)
+		return 1
+	}
+
+	archive, err := newArchiveStorage(input, format, absOutputDir, logger)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	count, err := archive.ExtractTo(absOutputDir, bingclient.NewFileStorage(logger), overwrite)
+	fmt.Printf("\n导入完成: 共 %d 个文件写入 %s\n", count, absOutputDir)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newArchiveStorage 创建归档存储，format 为空时根据扩展名推断格式
+func newArchiveStorage(archivePath, format, baseDir string, logger bingclient.Logger) (*bingclient.ArchiveStorage, error) {
+	if format == "" {
+		return bingclient.NewArchiveStorage(archivePath, baseDir, logger)
+	}
+
+	archiveFormat, err := bingclient.ParseArchiveFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return bingclient.NewArchiveStorageWithFormat(archivePath, baseDir, archiveFormat, logger), nil
+}
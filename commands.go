@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// subcommand 是子命令的处理函数，参数为子命令之后的命令行参数，返回进程退出码
+type subcommand func(args []string) int
+
+// subcommands 注册所有子命令，未匹配时执行默认的下载流程
+var subcommands = map[string]subcommand{
+	"daemon": runDaemon,
+	"dedupe": runDedupe,
+	"digest": runDigest,
+	"export": runExport,
+	"import": runImport,
+	"prune":  runPrune,
+	"set":    runSet,
+	"theme":  runTheme,
+	"verify": runVerify,
+}
+
+// newLogger 根据日志级别名称创建日志记录器
+func newLogger(logLevel string, noTime bool) *bingclient.DefaultLogger {
+	// 设置日志级别
+	var level bingclient.LogLevel
+	switch logLevel {
+	case "debug":
+		level = bingclient.LogLevelDebug
+	case "info":
+		level = bingclient.LogLevelInfo
+	case "warning":
+		level = bingclient.LogLevelWarning
+	case "error":
+		level = bingclient.LogLevelError
+	default:
+		fmt.Printf("警告: 无效的日志级别 '%s'，使用默认级别 'info'\n", logLevel)
+		level = bingclient.LogLevelInfo
+	}
+
+	return bingclient.NewLogger(
+		bingclient.WithLevel(level),
+		bingclient.WithTimeDisplay(!noTime),
+	)
+}
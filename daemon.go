@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// 到了切换时刻但新壁纸尚未发布时，按指数退避短暂重试的参数
+const (
+	rolloverRetryMin      = time.Minute
+	rolloverRetryMax      = 5 * time.Minute
+	rolloverRetryAttempts = 6
+)
+
+// daemonConfig 是 daemon 子命令的配置，由命令行参数和 -config 指定的 JSON 配置文件合并而成
+type daemonConfig struct {
+	download   *downloadOptions
+	configPath string
+	schedule   bingclient.Schedule
+	jitter     time.Duration
+	retryMin   time.Duration
+	retryMax   time.Duration
+	runAtStart bool
+
+	rollover      bool          // 在壁纸切换时刻之后检查
+	rolloverDelay time.Duration // 切换时刻之后的等待时间
+}
+
+// nextRun 返回 now 之后的下一次计划执行时间，包含随机延迟
+// 启用 -rollover 且已知最新的壁纸时，在其切换时刻之后检查；否则按调度计划
+func (c *daemonConfig) nextRun(now time.Time, latest *bingclient.ImageData) (time.Time, error) {
+	if c.rollover && latest != nil && !bingclient.IsRolloverPending(latest, now) {
+		if rollover, err := latest.NextRolloverTime(now); err == nil {
+			return rollover.Add(c.rolloverDelay + bingclient.Jitter(c.jitter)), nil
+		}
+	}
+
+	next := c.schedule.Next(now)
+	if next.IsZero() {
+		return next, fmt.Errorf("调度计划 %v 没有下一次执行时间", c.schedule)
+	}
+	return next.Add(bingclient.Jitter(c.jitter)), nil
+}
+
+// describeSchedule 返回调度计划的描述
+func (c *daemonConfig) describeSchedule() string {
+	if c.rollover {
+		return fmt.Sprintf("壁纸切换后 %v（无法确定切换时刻时%v）", c.rolloverDelay, c.schedule)
+	}
+	return fmt.Sprint(c.schedule)
+}
+
+// loadDaemonConfig 解析命令行参数并读取配置文件，命令行中显式指定的参数优先于配置文件
+// 收到 SIGHUP 时会以相同的参数重新调用，以重新读取配置文件
+func loadDaemonConfig(args []string) (*daemonConfig, error) {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	cfg := &daemonConfig{download: &downloadOptions{}}
+	cfg.download.register(fs)
+
+	var cronExpr string
+	var interval time.Duration
+	fs.StringVar(&cfg.configPath, "config", "", "JSON 配置文件，键与命令行参数同名（不含 -），收到 SIGHUP 时重新读取")
+	fs.StringVar(&cronExpr, "cron", "", "cron 表达式（分 时 日 月 周），如 \"5 8 * * *\"，指定后忽略 -interval")
+	fs.DurationVar(&interval, "interval", time.Hour, "检查新壁纸的间隔")
+	fs.DurationVar(&cfg.jitter, "jitter", 5*time.Minute, "每次检查前的最大随机延迟")
+	fs.DurationVar(&cfg.retryMin, "retry-min", time.Minute, "检查失败后首次重试的间隔，之后按指数退避")
+	fs.DurationVar(&cfg.retryMax, "retry-max", time.Hour, "检查失败后重试的最大间隔")
+	fs.BoolVar(&cfg.runAtStart, "run-at-start", true, "启动后立即检查一次")
+	fs.BoolVar(&cfg.rollover, "rollover", true, "根据当前壁纸的切换时刻安排下一次检查，无法确定时按 -interval；指定 -cron 时不生效")
+	fs.DurationVar(&cfg.rolloverDelay, "rollover-delay", time.Minute, "壁纸切换时刻之后等待多久再检查")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("多余的参数: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if cfg.configPath != "" {
+		if err := applyConfigFile(fs, cfg.configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	o := cfg.download
+	// 每次检查都会覆盖最近 -days 天，已下载的壁纸不再重复下载
+	o.skipExist = true
+	if o.dateStr != "" || o.fromStr != "" || o.toStr != "" || o.customName != "" {
+		return nil, fmt.Errorf("daemon 不支持 -date、-from、-to 和 -name")
+	}
+	if cfg.retryMin <= 0 || cfg.retryMax < cfg.retryMin {
+		return nil, fmt.Errorf("-retry-min 必须大于 0 且不大于 -retry-max")
+	}
+	if cfg.jitter < 0 || cfg.rolloverDelay < 0 {
+		return nil, fmt.Errorf("-jitter 和 -rollover-delay 不能为负数")
+	}
+
+	if cronExpr != "" {
+		schedule, err := bingclient.ParseCronSchedule(cronExpr, nil)
+		if err != nil {
+			return nil, err
+		}
+		cfg.schedule = schedule
+		cfg.rollover = false
+	} else {
+		if interval < time.Minute {
+			return nil, fmt.Errorf("-interval 不能小于 1 分钟")
+		}
+		cfg.schedule = bingclient.IntervalSchedule{Interval: interval}
+	}
+	return cfg, nil
+}
+
+// applyConfigFile 读取 JSON 配置文件，将其中的值设置到命令行中未显式指定的参数上
+// 值可以是字符串、数字、布尔值或字符串数组（以逗号连接，用于 thumbs、crop 等参数；on-new 等可重复的参数则逐项设置）
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "config" || fs.Lookup(key) == nil {
+			return fmt.Errorf("配置文件 %s 中有未知的配置项: %s", path, key)
+		}
+		if explicit[key] {
+			continue
+		}
+
+		// 可重复指定的参数，数组中的每一项分别设置
+		if _, ok := fs.Lookup(key).Value.(*stringList); ok {
+			items, isList := values[key].([]interface{})
+			if !isList {
+				items = []interface{}{values[key]}
+			}
+			for _, item := range items {
+				text, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("配置项 %s 的类型无效", key)
+				}
+				if err := fs.Set(key, text); err != nil {
+					return fmt.Errorf("配置项 %s 无效: %v", key, err)
+				}
+			}
+			continue
+		}
+
+		var text string
+		switch value := values[key].(type) {
+		case string:
+			text = value
+		case bool:
+			text = strconv.FormatBool(value)
+		case float64:
+			text = strconv.FormatFloat(value, 'f', -1, 64)
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			text = strings.Join(items, ",")
+		default:
+			return fmt.Errorf("配置项 %s 的类型无效", key)
+		}
+		if err := fs.Set(key, text); err != nil {
+			return fmt.Errorf("配置项 %s 无效: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// runDaemon 常驻运行，按调度计划检查并下载新壁纸
+// 默认在当前壁纸的切换时刻之后检查，新壁纸尚未发布时短暂重试；检查失败时按指数退避重试；收到 SIGTERM 或 SIGINT 时在当前检查完成后退出，收到 SIGHUP 时重新读取配置
+func runDaemon(args []string) int {
+	cfg, err := loadDaemonConfig(args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	logger := newLogger(cfg.download.logLevel, cfg.download.noTime)
+	job, err := newDownloadJob(cfg.download, logger)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	backoff := bingclient.NewBackoff(cfg.retryMin, cfg.retryMax)
+	rolloverBackoff := bingclient.NewBackoff(rolloverRetryMin, rolloverRetryMax)
+	rolloverGaveUp := false // 本次切换的快速重试是否已经用尽
+	var latest *bingclient.ImageData
+	next := time.Now()
+	if !cfg.runAtStart {
+		if next, err = cfg.nextRun(time.Now(), nil); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return 1
+		}
+	}
+	logger.Info("守护进程已启动 (pid %d)，调度计划: %s，目录: %s", os.Getpid(), cfg.describeSchedule(), job.absOutputDir)
+
+	for {
+		logger.Info("下一次检查: %s", next.Format("2006-01-02 15:04:05"))
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case sig := <-signals:
+			timer.Stop()
+			if sig != syscall.SIGHUP {
+				logger.Info("收到信号 %v，正在退出", sig)
+				return 0
+			}
+
+			logger.Info("收到 SIGHUP，正在重新加载配置")
+			newCfg, err := loadDaemonConfig(args)
+			if err != nil {
+				logger.Error("重新加载配置失败，继续使用原配置: %v", err)
+				continue
+			}
+			reloadedLogger := newLogger(newCfg.download.logLevel, newCfg.download.noTime)
+			newJob, err := newDownloadJob(newCfg.download, reloadedLogger)
+			if err != nil {
+				logger.Error("重新加载配置失败，继续使用原配置: %v", err)
+				continue
+			}
+			cfg, logger, job = newCfg, reloadedLogger, newJob
+			backoff = bingclient.NewBackoff(cfg.retryMin, cfg.retryMax)
+			if next, err = cfg.nextRun(time.Now(), latest); err != nil {
+				logger.Error("%v", err)
+				return 1
+			}
+			logger.Info("配置已重新加载，调度计划: %s", cfg.describeSchedule())
+			continue
+		case <-timer.C:
+		}
+
+		results, err := runDaemonTick(job)
+		if err != nil {
+			delay := backoff.Next()
+			logger.Warning("检查失败 (连续 %d 次): %v，%v 后重试", backoff.Attempts(), err, delay.Round(time.Second))
+			next = time.Now().Add(delay)
+			continue
+		}
+		backoff.Reset()
+
+		if newest := newestImage(results); newest != nil {
+			latest = newest
+		}
+		// 已到切换时刻但 Bing 还未发布新壁纸，短暂重试
+		// 重试用尽后保持用尽状态，直到新壁纸发布，避免之后每次按计划检查时都重新开始快速重试
+		if cfg.rollover && latest != nil && bingclient.IsRolloverPending(latest, time.Now()) {
+			if rolloverBackoff.Attempts() < rolloverRetryAttempts {
+				delay := rolloverBackoff.Next()
+				logger.Info("新壁纸尚未发布，%v 后重试", delay.Round(time.Second))
+				next = time.Now().Add(delay)
+				continue
+			}
+			if !rolloverGaveUp {
+				logger.Warning("新壁纸仍未发布，改为按调度计划检查")
+				rolloverGaveUp = true
+			}
+		} else {
+			rolloverBackoff.Reset()
+			rolloverGaveUp = false
+		}
+
+		if next, err = cfg.nextRun(time.Now(), latest); err != nil {
+			logger.Error("%v", err)
+			return 1
+		}
+	}
+}
+
+// runDaemonTick 执行一次下载，按 -set 设置桌面壁纸，并按 -retention 清理旧壁纸；有壁纸下载失败时返回错误
+func runDaemonTick(job *downloadJob) ([]*bingclient.DownloadResult, error) {
+	results, err := job.run()
+	if err != nil {
+		return nil, err
+	}
+	if failed := job.printSummary(results); failed > 0 {
+		return results, fmt.Errorf("%d 张壁纸下载失败", failed)
+	}
+	if err := job.setDesktop(results); err != nil {
+		job.logger.Warning("设置桌面壁纸失败: %v", err)
+	}
+	if err := job.prune(); err != nil {
+		job.logger.Warning("清理旧壁纸失败: %v", err)
+	}
+	return results, nil
+}
+
+// newestImage 返回下载结果中开始展示时间最晚的壁纸，没有可用的时间时返回 nil
+func newestImage(results []*bingclient.DownloadResult) *bingclient.ImageData {
+	var newest *bingclient.ImageData
+	var newestStart time.Time
+	for _, result := range results {
+		start, err := result.ImageData.StartTime()
+		if err != nil {
+			continue
+		}
+		if newest == nil || start.After(newestStart) {
+			newest, newestStart = &result.ImageData, start
+		}
+	}
+	return newest
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// runDedupe 查找壁纸目录中内容相同或相近的图片，并报告、硬链接或删除重复项
+func runDedupe(args []string) int {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	var (
+		inputDir  string
+		threshold int
+		action    string
+		dryRun    bool
+		yes       bool
+		logLevel  string
+		noTime    bool
+	)
+	fs.StringVar(&inputDir, "dir", "./bing_wallpapers", "壁纸目录")
+	fs.IntVar(&threshold, "threshold", bingclient.DefaultDuplicateThreshold, "判定为重复的最大感知哈希距离 (0-64)")
+	fs.StringVar(&action, "action", "report", "对重复图片的处理方式 (report, hardlink, delete)")
+	fs.BoolVar(&dryRun, "dry-run", false, "仅列出将被硬链接或删除的壁纸，不修改文件")
+	fs.BoolVar(&yes, "yes", false, "硬链接或删除前不再确认")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	if action != "report" && action != "hardlink" && action != "delete" {
+		fmt.Printf("错误: action 参数必须为 report、hardlink 或 delete\n")
+		return 1
+	}
+	if threshold < 0 || threshold > 64 {
+		fmt.Printf("错误: threshold 参数必须在 0 到 64 之间\n")
+		return 1
+	}
+
+	logger := newLogger(logLevel, noTime)
+
+	absInputDir, err := filepath.Abs(inputDir)
+	if err != nil {
+		fmt.Printf("错误: 无法获取绝对路径: %v\n", err)
+		return 1
+	}
+
+	wallpapers, err := bingclient.ScanWallpapers(absInputDir)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	logger.Info("共找到 %d 张壁纸，正在计算感知哈希...", len(wallpapers))
+
+	groups, errs := bingclient.FindDuplicates(wallpapers, threshold, logger)
+	aliases := aliasFiles(absInputDir)
+
+	// 分组具有传递性，组内的壁纸与保留壁纸的距离可能超过阈值，这类壁纸只报告不处理
+	type pendingDuplicate struct {
+		keep *bingclient.LocalWallpaper
+		dup  *bingclient.LocalWallpaper
+	}
+	var pending []pendingDuplicate
+	var duplicates, similar int
+	for _, group := range groups {
+		fmt.Printf("\n保留: %s\n", relPath(absInputDir, group.Keep.Path))
+		for i, dup := range group.Duplicates {
+			if group.Distances[i] > threshold {
+				similar++
+				fmt.Printf("  相近 (距离 %d，超过阈值，不处理): %s\n", group.Distances[i], relPath(absInputDir, dup.Path))
+				continue
+			}
+			duplicates++
+			fmt.Printf("  重复 (距离 %d): %s\n", group.Distances[i], relPath(absInputDir, dup.Path))
+			pending = append(pending, pendingDuplicate{keep: group.Keep, dup: dup})
+		}
+	}
+
+	summary := fmt.Sprintf("共 %d 组，%d 张重复壁纸", len(groups), duplicates)
+	if similar > 0 {
+		summary += fmt.Sprintf("，%d 张仅与组内其他壁纸相近", similar)
+	}
+	if action == "report" || len(pending) == 0 {
+		fmt.Printf("\n%s\n", summary)
+		return dedupeExitCode(errs)
+	}
+
+	verb := map[string]string{"hardlink": "硬链接", "delete": "删除"}[action]
+	if dryRun {
+		fmt.Printf("\n%s，将%s %d 张\n", summary, verb, len(pending))
+		return dedupeExitCode(errs)
+	}
+	if !yes && !confirm(os.Stdin, fmt.Sprintf("\n确认%s以上 %d 张重复壁纸？[y/N] ", verb, len(pending))) {
+		fmt.Printf("已取消\n")
+		return 0
+	}
+
+	var handled int
+	for _, p := range pending {
+		switch action {
+		case "hardlink":
+			linked, err := hardlinkDuplicate(p.keep.Path, p.dup.Path)
+			if err != nil {
+				logger.Error("硬链接 %s 失败: %v", p.dup.Path, err)
+				errs = append(errs, err)
+				continue
+			}
+			if !linked {
+				continue
+			}
+			// 图片内容已变为保留图片，同步更新元数据中的校验和
+			if err := p.dup.RefreshChecksum(bingclient.NewFileStorage(nil)); err != nil {
+				logger.Warning("更新 %s 的元数据失败: %v", p.dup.Path, err)
+			}
+			for _, path := range []string{p.dup.Path, p.dup.MetadataPath} {
+				if err := bingclient.UpdateManifest(path); err != nil {
+					logger.Warning("更新校验和清单失败: %v", err)
+				}
+			}
+			handled++
+		case "delete":
+			if alias := referencingAlias(aliases, p.dup.Path); alias != "" {
+				logger.Warning("跳过 %s: 仍被别名 %s 引用", p.dup.Path, filepath.Base(alias))
+				continue
+			}
+			if _, err := p.dup.Remove(logger); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			handled++
+		}
+	}
+	fmt.Printf("\n%s，已%s %d 张\n", summary, verb, handled)
+	return dedupeExitCode(errs)
+}
+
+// dedupeExitCode 根据处理失败的文件数返回退出码
+func dedupeExitCode(errs []error) int {
+	if len(errs) > 0 {
+		fmt.Printf("错误: %d 个文件处理失败\n", len(errs))
+		return 1
+	}
+	return 0
+}
+
+// confirm 输出提示并从 r 读取一行，只有输入 y 或 yes 时返回 true
+func confirm(r io.Reader, prompt string) bool {
+	fmt.Print(prompt)
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// hardlinkDuplicate 将重复图片替换为指向保留图片的硬链接
+// 先在同一目录中创建临时链接再重命名，替换过程是原子的；两者已经是同一文件时返回 false
+func hardlinkDuplicate(keepPath, dupPath string) (bool, error) {
+	keepInfo, err := os.Stat(keepPath)
+	if err != nil {
+		return false, err
+	}
+	dupInfo, err := os.Stat(dupPath)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(keepInfo, dupInfo) {
+		return false, nil
+	}
+
+	tmpPath := dupPath + ".link.tmp"
+	os.Remove(tmpPath)
+	if err := os.Link(keepPath, tmpPath); err != nil {
+		return false, fmt.Errorf("创建硬链接失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, dupPath); err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("替换文件失败: %v", err)
+	}
+	return true, nil
+}
+
+// aliasFiles 返回目录中 latest、today-* 等别名文件的路径
+func aliasFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var aliases []string
+	for _, entry := range entries {
+		if !entry.IsDir() && bingclient.IsAliasFile(entry.Name()) {
+			aliases = append(aliases, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return aliases
+}
+
+// referencingAlias 返回指向 path 的别名（符号链接或硬链接），没有时返回空字符串
+func referencingAlias(aliases []string, path string) string {
+	target, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	for _, alias := range aliases {
+		if info, err := os.Stat(alias); err == nil && os.SameFile(info, target) {
+			return alias
+		}
+	}
+	return ""
+}
+
+// relPath 返回相对于 base 的路径，失败时返回原路径
+func relPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// runDigest 将一段时间内的壁纸整理为摘要邮件，通过 SMTP 发送
+func runDigest(args []string) int {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	var (
+		inputDir   string
+		since      string
+		to         stringList
+		from       string
+		subject    string
+		smtpAddr   string
+		smtpUser   string
+		smtpPass   string
+		security   string
+		insecure   bool
+		thumbWidth int
+		output     string
+		logLevel   string
+		noTime     bool
+	)
+	fs.StringVar(&inputDir, "dir", "./bing_wallpapers", "壁纸目录")
+	fs.StringVar(&since, "since", "7d", "包含最近多长时间的壁纸，如 7d、2w，或起始日期 2026-10-01")
+	fs.Var(&to, "to", "收件人，可重复指定")
+	fs.StringVar(&from, "from", "", "发件人，如 \"Bing 壁纸 <bing@example.com>\"，默认为 -smtp-user")
+	fs.StringVar(&subject, "subject", "", "邮件主题，默认为 \"Bing 壁纸 (起始日期 - 结束日期)\"")
+	fs.StringVar(&smtpAddr, "smtp", "localhost:587", "SMTP 服务器地址 (主机:端口)")
+	fs.StringVar(&smtpUser, "smtp-user", "", "SMTP 用户名，为空时不认证")
+	fs.StringVar(&smtpPass, "smtp-password", "", "SMTP 密码，默认读取环境变量 BING_SMTP_PASSWORD")
+	fs.StringVar(&security, "smtp-security", "starttls", "SMTP 加密方式 (starttls, tls, none)")
+	fs.BoolVar(&insecure, "smtp-insecure", false, "不校验 SMTP 服务器的证书（仅用于自签名证书的内网服务器）")
+	fs.IntVar(&thumbWidth, "thumb-width", bingclient.DefaultDigestThumbnailWidth, "邮件中缩略图的宽度")
+	fs.StringVar(&output, "output", "", "将邮件写入文件（- 表示标准输出）而不是发送，可用于预览")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	logger := newLogger(logLevel, noTime)
+	// 邮件写入标准输出时，日志和提示信息改为写入标准错误
+	status := os.Stdout
+	if output == "-" {
+		status = os.Stderr
+		logger = bingclient.NewLogger(
+			bingclient.WithLevel(logger.GetLevel()),
+			bingclient.WithTimeDisplay(!noTime),
+			bingclient.WithWriter(os.Stderr),
+		)
+	}
+
+	now := time.Now()
+	sinceDate, err := parseSince(since, now)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	if thumbWidth <= 0 {
+		fmt.Printf("错误: -thumb-width 必须大于 0\n")
+		return 1
+	}
+	smtpSecurity, err := bingclient.ParseSMTPSecurity(security)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	if from == "" {
+		from = smtpUser
+	}
+	if len(to) == 0 || from == "" {
+		fmt.Printf("错误: 需要指定 -to 和 -from (或 -smtp-user)\n")
+		return 1
+	}
+	if smtpPass == "" {
+		smtpPass = os.Getenv("BING_SMTP_PASSWORD")
+	}
+	host, _, err := net.SplitHostPort(smtpAddr)
+	if err != nil {
+		fmt.Printf("错误: 无效的 SMTP 服务器地址 %s (格式应为 主机:端口)\n", smtpAddr)
+		return 1
+	}
+
+	absInputDir, err := filepath.Abs(inputDir)
+	if err != nil {
+		fmt.Printf("错误: 无法获取绝对路径: %v\n", err)
+		return 1
+	}
+	wallpapers, err := bingclient.ScanWallpapers(absInputDir)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	selected := bingclient.SelectDigest(wallpapers, sinceDate)
+	if len(selected) == 0 {
+		fmt.Fprintf(status, "自 %s 以来没有新壁纸，不发送邮件\n", sinceDate.Format("2006-01-02"))
+		return 0
+	}
+	logger.Info("自 %s 以来共有 %d 张壁纸", sinceDate.Format("2006-01-02"), len(selected))
+
+	config := bingclient.SMTPConfig{
+		Addr:     smtpAddr,
+		Username: smtpUser,
+		Password: smtpPass,
+		Security: smtpSecurity,
+	}
+	if insecure {
+		config.TLSConfig = &tls.Config{ServerName: host, InsecureSkipVerify: true}
+	}
+	notifier := bingclient.NewEmailNotifier(config, from, to, logger)
+	notifier.ThumbnailWidth = thumbWidth
+	notifier.Subject = subject
+	if notifier.Subject == "" {
+		notifier.Subject = fmt.Sprintf("Bing 壁纸 (%s - %s)", sinceDate.Format("2006-01-02"), now.Format("2006-01-02"))
+	}
+
+	if output == "" {
+		if err := notifier.SendDigest(selected); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return 1
+		}
+		fmt.Printf("已发送包含 %d 张壁纸的摘要邮件\n", len(selected))
+		return 0
+	}
+
+	message, err := notifier.Message(selected, now)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	if output == "-" {
+		os.Stdout.Write(message)
+		return 0
+	}
+	if err := os.WriteFile(output, message, 0644); err != nil {
+		fmt.Printf("错误: 写入邮件失败: %v\n", err)
+		return 1
+	}
+	fmt.Printf("已将摘要邮件写入 %s\n", output)
+	return 0
+}
+
+// parseSince 解析 -since 参数：Nd 表示最近 N 天（含今天），Nw 表示最近 N 周，也可以是起始日期
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if n := len(value); n > 1 && (value[n-1] == 'd' || value[n-1] == 'w') {
+		count, err := strconv.Atoi(value[:n-1])
+		if err != nil || count <= 0 {
+			return time.Time{}, fmt.Errorf("无效的时间范围: %s (如 7d、2w)", value)
+		}
+		if value[n-1] == 'w' {
+			count *= 7
+		}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return today.AddDate(0, 0, 1-count), nil
+	}
+	date, err := parseDateFlag(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间范围: %s (如 7d、2w 或 2026-10-01)", value)
+	}
+	return date, nil
+}
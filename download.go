@@ -0,0 +1,468 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// downloadOptions 是下载流程的参数，默认的下载流程和 daemon 子命令共用
+type downloadOptions struct {
+	outputDir   string
+	days        int
+	highQuality bool
+	saveJson    bool
+	locale      string
+	logLevel    string
+	noTime      bool
+	lastOnly    bool
+	customName  string
+	overwrite   bool
+	archivePath string
+	nameTmpl    string
+	asciiOnly   bool
+	latestAlias bool
+	marketAlias bool
+	embedMeta   bool
+	metaFormat  string
+	dateStr     string
+	fromStr     string
+	toStr       string
+	thumbs      string
+	crop        string
+	cropMode    string
+	caption     bool
+	captionPos  string
+	captionFont string
+	captionSize float64
+	captionShdw bool
+	captionBack float64
+	palette     bool
+	manifest    bool
+	retention   string
+	setDesktop  bool
+	desktop     desktopOptions
+	onNew       stringList
+	hookTimeout time.Duration
+	webhook     webhookOptions
+	skipExist   bool
+}
+
+// stringList 是可以重复指定的字符串参数，每次指定追加一项
+type stringList []string
+
+// String 返回以分号分隔的所有项
+func (l *stringList) String() string {
+	return strings.Join(*l, "; ")
+}
+
+// Set 追加一项
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// register 在参数集中注册下载流程的命令行参数
+func (o *downloadOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.outputDir, "dir", "./bing_wallpapers", "壁纸保存目录")
+	fs.IntVar(&o.days, "days", 7, fmt.Sprintf("下载最近几天的壁纸 (1-%d)", bingclient.MaxRetentionDays))
+	fs.BoolVar(&o.highQuality, "hd", true, "下载高清壁纸")
+	fs.BoolVar(&o.saveJson, "json", false, "保存壁纸元数据文件")
+	fs.StringVar(&o.metaFormat, "meta-format", "json", "元数据文件格式 (json, yaml, xmp)")
+	fs.StringVar(&o.locale, "locale", "zh-CN", "语言区域 (zh-CN, en-US, ja-JP 等)")
+	fs.StringVar(&o.logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&o.noTime, "no-time", false, "日志中不显示时间戳")
+	fs.BoolVar(&o.lastOnly, "last", false, "仅下载最后一天的壁纸")
+	fs.StringVar(&o.dateStr, "date", "", "下载指定日期的壁纸 (如 2026-10-01)")
+	fs.StringVar(&o.fromStr, "from", "", "下载日期范围的开始日期 (如 2026-10-01)")
+	fs.StringVar(&o.toStr, "to", "", "下载日期范围的结束日期，默认为今天")
+	fs.StringVar(&o.customName, "name", "", "指定保存的文件名 (如 my-wallpaper.jpg)")
+	fs.BoolVar(&o.overwrite, "overwrite", false, "如果文件已存在则覆盖")
+	fs.StringVar(&o.nameTmpl, "name-template", "", "文件名模板，如 {yyyy}/{mm}/{date}_{market}_{title}_{res}.{ext}")
+	fs.BoolVar(&o.asciiOnly, "ascii", false, "文件名中的非 ASCII 字符音译为 ASCII")
+	fs.BoolVar(&o.latestAlias, "latest", false, "维护指向最新壁纸的 latest.jpg / latest.json")
+	fs.BoolVar(&o.marketAlias, "market-alias", false, "额外维护 today-<locale>.jpg 别名")
+	fs.BoolVar(&o.embedMeta, "embed-meta", false, "将标题、版权等信息以 XMP/IPTC 形式写入 JPEG")
+	fs.StringVar(&o.thumbs, "thumbs", "", "生成指定宽度的缩略图，以逗号分隔 (如 320,800)")
+	fs.StringVar(&o.crop, "crop", "", "生成指定分辨率的裁剪图，以逗号分隔 (如 3440x1440,1080x1920)")
+	fs.StringVar(&o.cropMode, "crop-mode", "fill", "裁剪方式 (fill, fit-blur-bars, center)")
+	fs.BoolVar(&o.caption, "caption", false, "生成叠加标题和版权信息的图片")
+	fs.StringVar(&o.captionPos, "caption-pos", "bottom-left", "文字位置 (bottom-left, bottom-right, bottom-center, top-left, top-right, top-center)")
+	fs.StringVar(&o.captionFont, "caption-font", "", "TTF/OTF/TTC 字体文件，多个以逗号分隔，按顺序回退；中日韩区域在系统中没有中日韩字体时必须指定")
+	fs.Float64Var(&o.captionSize, "caption-size", 40, "标题字号（按 1080 像素高度换算）")
+	fs.BoolVar(&o.captionShdw, "caption-shadow", true, "绘制文字阴影")
+	fs.Float64Var(&o.captionBack, "caption-backdrop", 0.4, "文字底色的不透明度 (0-1)，0 表示不绘制")
+	fs.BoolVar(&o.palette, "palette", false, "提取主色、强调色和调色板并写入元数据")
+	fs.BoolVar(&o.manifest, "manifest", false, "在每个目录的 SHA256SUMS 清单中记录保存文件的校验和")
+	fs.StringVar(&o.retention, "retention", "", "下载完成后按保留策略清理壁纸目录，如 keep-days=90,max-size=10GB,gfs")
+	fs.StringVar(&o.archivePath, "archive", "", "将壁纸写入 zip 或 tar.gz 归档而不是单独的文件")
+	fs.BoolVar(&o.setDesktop, "set", false, "下载完成后将最新的壁纸设置为桌面壁纸")
+	o.desktop.register(fs)
+	fs.Var(&o.onNew, "on-new", "新壁纸保存后执行的命令，可重复指定；壁纸信息通过 BING_* 环境变量和标准输入中的 JSON 传递")
+	fs.DurationVar(&o.hookTimeout, "hook-timeout", bingclient.DefaultHookTimeout, "-on-new 命令的超时时间")
+	fs.Var(&o.webhook.urls, "webhook", "新壁纸保存后通知的 Webhook 地址，可重复指定；可以用 slack=、discord=、matrix= 前缀指定格式")
+	fs.StringVar(&o.webhook.format, "webhook-format", "generic", "Webhook 请求体格式 (generic, slack, discord, matrix)")
+	fs.StringVar(&o.webhook.template, "webhook-template", "", "自定义 Webhook 请求体的 Go 模板文件，输出必须是 JSON")
+	fs.StringVar(&o.webhook.secret, "webhook-secret", "", "Webhook 签名密钥，默认读取环境变量 BING_WEBHOOK_SECRET")
+	fs.Var(&o.webhook.headers, "webhook-header", "Webhook 请求附加的请求头，格式为 \"名称: 值\"，可重复指定")
+	fs.IntVar(&o.webhook.retries, "webhook-retries", bingclient.DefaultWebhookRetries, "Webhook 请求失败时的重试次数")
+}
+
+// downloadJob 是根据参数创建的下载任务，可以重复执行
+type downloadJob struct {
+	opts       *downloadOptions
+	logger     bingclient.Logger
+	downloader *bingclient.Downloader
+	archive    *bingclient.ArchiveStorage
+
+	absOutputDir    string
+	date            time.Time
+	fromDate        time.Time
+	toDate          time.Time
+	thumbnailWidths []int
+	retentionPolicy *bingclient.RetentionPolicy
+}
+
+// newDownloadJob 校验参数并创建下载任务
+func newDownloadJob(o *downloadOptions, logger bingclient.Logger) (*downloadJob, error) {
+	job := &downloadJob{opts: o, logger: logger}
+
+	// 如果启用了仅下载最后一天，则强制设置 days 为 1
+	if o.lastOnly {
+		o.days = 1
+	}
+
+	// 校验参数
+	if o.days < 1 || o.days > bingclient.MaxRetentionDays {
+		return nil, fmt.Errorf("days参数必须在1到%d之间", bingclient.MaxRetentionDays)
+	}
+	if o.customName != "" && o.nameTmpl != "" {
+		return nil, fmt.Errorf("-name 与 -name-template 不能同时使用")
+	}
+	if o.manifest && o.archivePath != "" {
+		return nil, fmt.Errorf("-manifest 与 -archive 不能同时使用")
+	}
+	if o.retention != "" {
+		if o.archivePath != "" {
+			return nil, fmt.Errorf("-retention 与 -archive 不能同时使用")
+		}
+		policy, err := bingclient.ParseRetentionPolicy(o.retention)
+		if err != nil {
+			return nil, err
+		}
+		job.retentionPolicy = policy
+	}
+	if err := o.desktop.validate(); err != nil {
+		return nil, err
+	}
+	if o.desktop.multiMonitor() && !o.setDesktop {
+		return nil, fmt.Errorf("-monitors %s 需要与 -set 一起使用", o.desktop.monitors)
+	}
+	if o.setDesktop && o.archivePath != "" {
+		return nil, fmt.Errorf("-set 与 -archive 不能同时使用")
+	}
+	metadataFormat, err := bingclient.ParseMetadataFormat(o.metaFormat)
+	if err != nil {
+		return nil, err
+	}
+	if o.dateStr != "" && (o.fromStr != "" || o.toStr != "") {
+		return nil, fmt.Errorf("-date 与 -from/-to 不能同时使用")
+	}
+	if o.lastOnly && (o.dateStr != "" || o.fromStr != "" || o.toStr != "") {
+		return nil, fmt.Errorf("-last 与 -date/-from/-to 不能同时使用")
+	}
+	if o.toStr != "" && o.fromStr == "" {
+		return nil, fmt.Errorf("使用 -to 时必须同时指定 -from")
+	}
+	if o.dateStr != "" {
+		if job.date, err = parseDateFlag(o.dateStr); err != nil {
+			return nil, fmt.Errorf("-date %v", err)
+		}
+	}
+	if o.fromStr != "" {
+		if job.fromDate, err = parseDateFlag(o.fromStr); err != nil {
+			return nil, fmt.Errorf("-from %v", err)
+		}
+		job.toDate = bingclient.MarketToday(o.locale, time.Now())
+		if o.toStr != "" {
+			if job.toDate, err = parseDateFlag(o.toStr); err != nil {
+				return nil, fmt.Errorf("-to %v", err)
+			}
+		}
+	}
+	if job.thumbnailWidths, err = bingclient.ParseThumbnailWidths(o.thumbs); err != nil {
+		return nil, err
+	}
+	cropTargets, err := bingclient.ParseResolutions(o.crop)
+	if err != nil {
+		return nil, err
+	}
+	cropModeValue, err := bingclient.ParseCropMode(o.cropMode)
+	if err != nil {
+		return nil, err
+	}
+	captionPosition, err := bingclient.ParseCaptionPosition(o.captionPos)
+	if err != nil {
+		return nil, err
+	}
+	// 内置字体只包含西文字符，中日韩区域需要字体文件才能绘制标题
+	if o.caption && strings.TrimSpace(o.captionFont) == "" && bingclient.IsCJKLocale(o.locale) && bingclient.SystemCJKFont() == "" {
+		return nil, fmt.Errorf("区域 %s 的标题需要中日韩字体，系统中未找到，请使用 -caption-font 指定字体文件", o.locale)
+	}
+
+	// 获取绝对路径
+	if job.absOutputDir, err = filepath.Abs(o.outputDir); err != nil {
+		return nil, fmt.Errorf("无法获取绝对路径: %v", err)
+	}
+
+	// 创建 Bing 壁纸客户端
+	client := bingclient.NewClient(
+		bingclient.WithHighQuality(o.highQuality),
+		bingclient.WithLocale(o.locale),
+		bingclient.WithTimeout(15*time.Second),
+		bingclient.WithLogger(logger),
+	)
+
+	// 创建存储工具
+	storage := bingclient.NewBingImageStorage(job.absOutputDir, logger)
+	storage.LatestAlias = o.latestAlias
+	storage.EmbedMetadata = o.embedMeta
+	if o.marketAlias {
+		storage.MarketAlias = o.locale
+	}
+
+	// 如果指定了归档文件，将壁纸写入归档
+	if o.archivePath != "" {
+		archive, err := bingclient.NewArchiveStorage(o.archivePath, job.absOutputDir, logger)
+		if err != nil {
+			return nil, err
+		}
+		storage.SetStorage(archive)
+		job.archive = archive
+	}
+
+	// 记录保存文件的校验和
+	if o.manifest {
+		storage.SetStorage(bingclient.NewManifestStorage(storage.Storage, logger))
+	}
+
+	// 文件名清理器
+	sanitizer := bingclient.NewFilenameSanitizer()
+	sanitizer.ASCIIOnly = o.asciiOnly
+
+	defaultGenerator := bingclient.NewDefaultFilenameGenerator(logger)
+	defaultGenerator.Sanitizer = sanitizer
+	storage.SetFilenameGenerator(defaultGenerator)
+
+	// 如果指定了文件名模板，设置模板文件名生成器
+	if o.nameTmpl != "" {
+		templateGenerator, err := bingclient.NewTemplateFilenameGenerator(o.nameTmpl, logger)
+		if err != nil {
+			return nil, err
+		}
+		templateGenerator.Market = client.GetLocale()
+		templateGenerator.Resolution = client.GetResolution()
+		templateGenerator.Sanitizer = sanitizer
+		storage.SetFilenameGenerator(templateGenerator)
+	}
+
+	// 如果指定了自定义文件名，设置自定义文件名生成器
+	if o.customName != "" {
+		// 清理文件名，并确保不会超出输出目录
+		filename := sanitizer.Sanitize(o.customName)
+		if filename == "" {
+			return nil, fmt.Errorf("无效的文件名 %q", o.customName)
+		}
+		if filename != o.customName {
+			logger.Warning("文件名 %q 已清理为 %q", o.customName, filename)
+		}
+		if filepath.Ext(filename) == "" {
+			filename += ".jpg"
+		}
+		filePath, err := bingclient.SafeJoin(job.absOutputDir, filename)
+		if err != nil {
+			return nil, err
+		}
+
+		customGenerator := &CustomFilenameGenerator{
+			DefaultFilenameGenerator: *defaultGenerator,
+			CustomFilename:           filename,
+		}
+		storage.SetFilenameGenerator(customGenerator)
+
+		// 检查文件是否已存在且未指定覆盖
+		if !o.overwrite {
+			if fileExists(filePath) {
+				return nil, fmt.Errorf("文件 %s 已存在。使用 -overwrite 选项覆盖现有文件。", filePath)
+			}
+		}
+	}
+
+	// 创建下载器
+	downloader := bingclient.NewDownloader(client, storage)
+	// 设置是否保存元数据及其格式
+	downloader.SaveJsonData = o.saveJson
+	downloader.MetadataFormat = metadataFormat
+	// 设置图片处理流水线
+	pipeline := bingclient.NewImagePipeline(logger)
+	if o.saveJson {
+		// 元数据中记录感知哈希，供 dedupe 子命令查找重复图片
+		pipeline.AddAnalyzer(bingclient.NewHashAnalyzer())
+	}
+	if o.palette {
+		pipeline.AddAnalyzer(bingclient.NewPaletteAnalyzer(bingclient.DefaultPaletteSize))
+	}
+	if len(job.thumbnailWidths) > 0 {
+		pipeline.AddProcessor(bingclient.NewThumbnailProcessor(job.thumbnailWidths...))
+	}
+	if len(cropTargets) > 0 {
+		pipeline.AddProcessor(bingclient.NewCropProcessor(cropModeValue, cropTargets...))
+	}
+	if o.caption {
+		captionProcessor := bingclient.NewCaptionProcessor(logger)
+		captionProcessor.Position = captionPosition
+		captionProcessor.FontSize = o.captionSize
+		captionProcessor.Shadow = o.captionShdw
+		captionProcessor.BackdropOpacity = o.captionBack
+		for _, path := range strings.Split(o.captionFont, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				captionProcessor.FontPaths = append(captionProcessor.FontPaths, path)
+			}
+		}
+		pipeline.AddProcessor(captionProcessor)
+	}
+	if !pipeline.Empty() {
+		downloader.Pipeline = pipeline
+	}
+	// 新壁纸保存后执行的命令
+	for _, command := range o.onNew {
+		if strings.TrimSpace(command) == "" {
+			return nil, fmt.Errorf("-on-new 命令不能为空")
+		}
+		downloader.AddHook(bingclient.NewCommandHook(command, o.hookTimeout, logger))
+	}
+	// 新壁纸保存后通知的 Webhook
+	notifiers, err := newWebhookNotifiers(&o.webhook, logger)
+	if err != nil {
+		return nil, err
+	}
+	for _, notifier := range notifiers {
+		downloader.AddHook(notifier)
+	}
+	downloader.SkipExisting = o.skipExist && !o.overwrite
+	job.downloader = downloader
+
+	return job, nil
+}
+
+// singleImage 判断是否只下载一张壁纸，此时显示详细信息
+func (j *downloadJob) singleImage() bool {
+	return j.opts.lastOnly || j.opts.dateStr != ""
+}
+
+// run 根据参数选择下载方法并下载壁纸，写入归档时在下载结束后一次性写入
+func (j *downloadJob) run() ([]*bingclient.DownloadResult, error) {
+	results, err := j.fetch()
+	if j.archive != nil {
+		if flushErr := j.archive.Close(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+	}
+	return results, err
+}
+
+// fetch 根据参数选择下载方法
+func (j *downloadJob) fetch() ([]*bingclient.DownloadResult, error) {
+	switch {
+	case j.opts.lastOnly:
+		j.logger.Info("仅下载最后一天的壁纸")
+		result, err := j.downloader.FetchAndSaveWallpaper(0)
+		if err != nil {
+			return nil, err
+		}
+		return []*bingclient.DownloadResult{result}, nil
+	case j.opts.dateStr != "":
+		result, err := j.downloader.FetchByDate(j.date)
+		if err != nil {
+			return nil, err
+		}
+		return []*bingclient.DownloadResult{result}, nil
+	case j.opts.fromStr != "":
+		return j.downloader.FetchRange(j.fromDate, j.toDate, true)
+	default:
+		// 下载壁纸（使用优化的批量下载方法）
+		return j.downloader.DownloadLatestWallpapers(j.opts.days, true)
+	}
+}
+
+// printSummary 输出结果摘要，返回下载失败的数量
+func (j *downloadJob) printSummary(results []*bingclient.DownloadResult) int {
+	var success, failed, unchanged int
+	for _, result := range results {
+		if result.DownloadErr == nil {
+			success++
+			if result.Unchanged {
+				unchanged++
+			}
+		} else {
+			failed++
+		}
+	}
+
+	if unchanged > 0 {
+		fmt.Printf("\n下载完成: 成功%d张（其中%d张已存在或未变化），失败%d张\n", success, unchanged, failed)
+	} else {
+		fmt.Printf("\n下载完成: 成功%d张，失败%d张\n", success, failed)
+	}
+
+	// 如果只下载了一张，显示更详细的信息
+	if j.singleImage() && len(results) > 0 && results[0].DownloadErr == nil {
+		result := results[0]
+		fmt.Printf("\n壁纸详情:\n")
+		fmt.Printf("标题: %s\n", result.ImageData.Title)
+		if formattedDate, err := bingclient.FormatDate(result.ImageData.Startdate); err == nil {
+			fmt.Printf("日期: %s\n", formattedDate)
+		}
+		fmt.Printf("描述: %s\n", result.ImageData.Copyright)
+		fmt.Printf("保存路径: %s\n", result.ImagePath)
+		if j.opts.saveJson && result.JsonPath != "" {
+			fmt.Printf("元数据: %s\n", result.JsonPath)
+		}
+		for _, width := range j.thumbnailWidths {
+			if path, ok := result.ThumbnailPaths[width]; ok {
+				fmt.Printf("缩略图 (%dpx): %s\n", width, path)
+			}
+		}
+		for _, variant := range result.Variants {
+			switch variant.Kind {
+			case bingclient.VariantCrop:
+				fmt.Printf("裁剪图 (%dx%d): %s\n", variant.Width, variant.Height, variant.Path)
+			case bingclient.VariantCaption:
+				fmt.Printf("文字图: %s\n", variant.Path)
+			}
+		}
+	}
+	return failed
+}
+
+// setDesktop 按 -set 将下载的壁纸设置为桌面壁纸，未指定时不做任何操作
+func (j *downloadJob) setDesktop(results []*bingclient.DownloadResult) error {
+	if !j.opts.setDesktop {
+		return nil
+	}
+	return applyDesktopWallpaper(results, &j.opts.desktop, j.logger)
+}
+
+// prune 按 -retention 指定的保留策略清理旧壁纸，未指定时不做任何操作
+func (j *downloadJob) prune() error {
+	if j.retentionPolicy == nil {
+		return nil
+	}
+	fmt.Println()
+	policy := *j.retentionPolicy
+	return applyRetention(j.absOutputDir, &policy, "", false, j.logger)
+}
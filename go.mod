@@ -1,3 +1,7 @@
 module github.com/DeyiXu/bingWallpaper
 
 go 1.23.6
+
+require golang.org/x/image v0.30.0
+
+require golang.org/x/text v0.28.0 // indirect
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+	"github.com/DeyiXu/bingWallpaper/pkg/wallpaper"
+)
+
+// 多显示器模式
+const (
+	monitorsSingle = "single"  // 所有显示器使用同一张壁纸
+	monitorsPerDay = "per-day" // 每台显示器使用不同日期的壁纸，主显示器使用最新的
+	monitorsSpan   = "span"    // 一张壁纸跨越所有显示器
+)
+
+// desktopOptions 是设置桌面壁纸的参数，set 子命令和下载流程的 -set 共用
+type desktopOptions struct {
+	setter   string
+	dark     string
+	monitors string
+	layout   string
+}
+
+// register 在参数集中注册设置方式和多显示器参数
+func (o *desktopOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.setter, "setter", "auto", "壁纸设置方式，auto 表示根据 XDG_CURRENT_DESKTOP 自动检测")
+	fs.StringVar(&o.monitors, "monitors", monitorsSingle, "多显示器模式 (single, per-day, span)")
+	fs.StringVar(&o.layout, "layout", "", "显示器布局 JSON 文件，默认通过 wlr-randr 或 xrandr 检测")
+}
+
+// validate 检查多显示器模式
+func (o *desktopOptions) validate() error {
+	switch o.monitors {
+	case "", monitorsSingle, monitorsPerDay, monitorsSpan:
+	default:
+		return fmt.Errorf("不支持的多显示器模式: %s (可选 %s, %s, %s)", o.monitors, monitorsSingle, monitorsPerDay, monitorsSpan)
+	}
+	if o.layout != "" && !o.multiMonitor() {
+		return fmt.Errorf("-layout 需要与 -monitors %s 或 %s 一起使用", monitorsPerDay, monitorsSpan)
+	}
+	return nil
+}
+
+// multiMonitor 判断是否为每台显示器分别设置壁纸
+func (o *desktopOptions) multiMonitor() bool {
+	return o.monitors == monitorsPerDay || o.monitors == monitorsSpan
+}
+
+// loadLayout 读取 -layout 指定的显示器布局，未指定时通过 wlr-randr 或 xrandr 检测
+func (o *desktopOptions) loadLayout(logger bingclient.Logger) (*wallpaper.Layout, error) {
+	var layout *wallpaper.Layout
+	var err error
+	if o.layout != "" {
+		layout, err = wallpaper.LoadLayout(o.layout)
+	} else {
+		layout, err = wallpaper.DetectLayout()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range layout.Ordered() {
+		logger.Debug("显示器 %s: %dx%d+%d+%d", m.Name, m.Width, m.Height, m.X, m.Y)
+	}
+	return layout, nil
+}
+
+// applyDesktopWallpaper 使用下载结果设置桌面壁纸
+// 单显示器模式使用最新的壁纸；多显示器模式先通过图片处理流水线生成每台显示器的图片，再交给设置方式
+func applyDesktopWallpaper(results []*bingclient.DownloadResult, o *desktopOptions, logger bingclient.Logger) error {
+	results = newestFirst(results)
+	if len(results) == 0 {
+		return fmt.Errorf("没有可以设置的壁纸")
+	}
+
+	if !o.multiMonitor() {
+		img, err := wallpaper.Image{Path: results[0].ImagePath, DarkPath: o.dark}.Resolve()
+		if err != nil {
+			return err
+		}
+		return setWallpaper(img, o.setter, logger)
+	}
+
+	layout, err := o.loadLayout(logger)
+	if err != nil {
+		return err
+	}
+	return applyMonitorWallpapers(results, o, layout, logger)
+}
+
+// applyMonitorWallpapers 按多显示器模式为布局中的每台显示器设置壁纸，results 按从新到旧排列
+func applyMonitorWallpapers(results []*bingclient.DownloadResult, o *desktopOptions, layout *wallpaper.Layout, logger bingclient.Logger) error {
+	setter, err := resolveSetter(o.setter)
+	if err != nil {
+		return err
+	}
+	if o.monitors == monitorsSpan {
+		return applySpanWallpaper(setter, layout, results[0], logger)
+	}
+	return applyPerDayWallpapers(setter, layout, results, logger)
+}
+
+// applyPerDayWallpapers 按主显示器优先的顺序为每台显示器分配一张壁纸，并裁剪为显示器的分辨率
+// 壁纸少于显示器时循环使用
+func applyPerDayWallpapers(setter wallpaper.Setter, layout *wallpaper.Layout, results []*bingclient.DownloadResult, logger bingclient.Logger) error {
+	monitors := layout.Ordered()
+	if len(results) < len(monitors) {
+		logger.Warning("只有 %d 张壁纸，少于 %d 台显示器，部分显示器将使用相同的壁纸", len(results), len(monitors))
+	}
+
+	// 同一张壁纸可能分配给多台显示器，合并后每张壁纸只运行一次流水线
+	targets := make(map[*bingclient.DownloadResult][]bingclient.Resolution)
+	assigned := make(map[string]*bingclient.DownloadResult, len(monitors))
+	for i, m := range monitors {
+		result := results[i%len(results)]
+		assigned[m.Name] = result
+		targets[result] = append(targets[result], bingclient.Resolution{Width: m.Width, Height: m.Height})
+	}
+	for result, resolutions := range targets {
+		if err := runMonitorPipeline(result, bingclient.NewCropProcessor(bingclient.CropFill, resolutions...), logger); err != nil {
+			return err
+		}
+	}
+
+	images := make(map[string]wallpaper.Image, len(monitors))
+	for _, m := range monitors {
+		result := assigned[m.Name]
+		path := bingclient.CropPath(result.ImagePath, bingclient.Resolution{Width: m.Width, Height: m.Height}, bingclient.CropFill)
+		img, err := wallpaper.Image{Path: path}.Resolve()
+		if err != nil {
+			return err
+		}
+		images[m.Name] = img
+		logger.Info("显示器 %s: %s", m.Name, resultLabel(result))
+	}
+
+	logger.Info("使用 %s 为 %d 台显示器设置壁纸", setter.Name(), len(monitors))
+	if err := wallpaper.SetMonitors(setter, layout, images); err != nil {
+		if errors.Is(err, wallpaper.ErrUnsupported) {
+			return fmt.Errorf("设置壁纸失败: %v，请使用 -monitors %s 或 -setter 指定其他设置方式", err, monitorsSpan)
+		}
+		return fmt.Errorf("设置壁纸失败: %v", err)
+	}
+	logger.Info("壁纸设置成功")
+	return nil
+}
+
+// applySpanWallpaper 将壁纸铺满所有显示器组成的画布，切分后为每台显示器设置对应的部分
+func applySpanWallpaper(setter wallpaper.Setter, layout *wallpaper.Layout, result *bingclient.DownloadResult, logger bingclient.Logger) error {
+	regions := make([]bingclient.SpanRegion, 0, len(layout.Monitors))
+	for _, m := range layout.Monitors {
+		regions = append(regions, bingclient.SpanRegion{
+			Name:   m.Name,
+			Bounds: m.LogicalBounds(),
+			Size:   bingclient.Resolution{Width: m.Width, Height: m.Height},
+		})
+	}
+	if err := runMonitorPipeline(result, bingclient.NewSpanProcessor(regions...), logger); err != nil {
+		return err
+	}
+
+	canvas, err := wallpaper.Image{Path: bingclient.SpanPath(result.ImagePath, bingclient.SpanCanvasName)}.Resolve()
+	if err != nil {
+		return err
+	}
+	images := make(map[string]wallpaper.Image, len(layout.Monitors))
+	for _, m := range layout.Monitors {
+		img, err := wallpaper.Image{Path: bingclient.SpanPath(result.ImagePath, m.Name)}.Resolve()
+		if err != nil {
+			return err
+		}
+		images[m.Name] = img
+	}
+
+	logger.Info("使用 %s 将壁纸跨越 %d 台显示器: %s", setter.Name(), len(layout.Monitors), resultLabel(result))
+	if err := wallpaper.SetSpanned(setter, layout, canvas, images); err != nil {
+		return fmt.Errorf("设置壁纸失败: %v", err)
+	}
+	logger.Info("壁纸设置成功")
+	return nil
+}
+
+// runMonitorPipeline 读取已保存的壁纸，通过图片处理流水线生成显示器使用的派生图片
+func runMonitorPipeline(result *bingclient.DownloadResult, processor bingclient.ImageProcessor, logger bingclient.Logger) error {
+	data, err := os.ReadFile(result.ImagePath)
+	if err != nil {
+		return fmt.Errorf("读取壁纸失败: %v", err)
+	}
+	pipeline := bingclient.NewImagePipeline(logger, processor)
+	saved, errs := pipeline.Run(data, &result.ImageData, result.Metadata, result.ImagePath, bingclient.NewFileStorage(logger))
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	result.Variants = append(result.Variants, saved...)
+	return nil
+}
+
+// resultLabel 返回用于日志的壁纸名称，没有标题时使用文件名
+func resultLabel(result *bingclient.DownloadResult) string {
+	if result.ImageData.Title != "" {
+		return result.ImageData.Title
+	}
+	return filepath.Base(result.ImagePath)
+}
+
+// newestFirst 返回下载成功的结果，按开始展示时间从新到旧排列
+func newestFirst(results []*bingclient.DownloadResult) []*bingclient.DownloadResult {
+	var succeeded []*bingclient.DownloadResult
+	for _, result := range results {
+		if result != nil && result.DownloadErr == nil && result.ImagePath != "" {
+			succeeded = append(succeeded, result)
+		}
+	}
+	sort.SliceStable(succeeded, func(i, j int) bool {
+		return succeeded[i].ImageData.Startdate > succeeded[j].ImageData.Startdate
+	})
+	return succeeded
+}
+
+// latestWallpaper 返回壁纸目录中的 latest.jpg，未启用 -latest 时使用目录中日期最新的壁纸
+func latestWallpaper(dir string) (string, error) {
+	alias := filepath.Join(dir, bingclient.LatestAliasName+".jpg")
+	if fileExists(alias) {
+		return alias, nil
+	}
+	results, err := localResults(dir, 1)
+	if err != nil {
+		return "", err
+	}
+	return results[0].ImagePath, nil
+}
+
+// localResults 将壁纸目录中最新的 count 张壁纸转换为下载结果，供 set 子命令使用
+func localResults(dir string, count int) ([]*bingclient.DownloadResult, error) {
+	wallpapers, err := bingclient.ScanWallpapers(dir)
+	if err != nil {
+		return nil, err
+	}
+	var results []*bingclient.DownloadResult
+	for i := len(wallpapers) - 1; i >= 0 && len(results) < count; i-- {
+		w := wallpapers[i]
+		result := &bingclient.DownloadResult{
+			ImageData: bingclient.ImageData{Startdate: w.Date()},
+			Metadata:  w.Metadata,
+			ImagePath: w.Path,
+		}
+		if w.Metadata != nil {
+			result.ImageData.Title = w.Metadata.Title
+			result.ImageData.Copyright = w.Metadata.Copyright
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有壁纸", dir)
+	}
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// webhookOptions 是 Webhook 通知的参数
+type webhookOptions struct {
+	urls     stringList
+	format   string
+	template string
+	secret   string
+	headers  stringList
+	retries  int
+}
+
+// newWebhookNotifiers 根据参数创建 Webhook 通知
+// -webhook 的值可以带格式前缀，如 slack=https://hooks.slack.com/...，否则使用 -webhook-format
+// 未指定 -webhook-secret 时读取环境变量 BING_WEBHOOK_SECRET，避免密钥出现在进程列表中
+func newWebhookNotifiers(o *webhookOptions, logger bingclient.Logger) ([]*bingclient.WebhookNotifier, error) {
+	if len(o.urls) == 0 {
+		return nil, nil
+	}
+	defaultFormat, err := bingclient.ParseWebhookFormat(o.format)
+	if err != nil {
+		return nil, err
+	}
+	if o.retries < 0 {
+		return nil, fmt.Errorf("-webhook-retries 不能为负数")
+	}
+
+	var tmpl *template.Template
+	if o.template != "" {
+		text, err := os.ReadFile(o.template)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Webhook 模板失败: %v", err)
+		}
+		if tmpl, err = bingclient.ParseWebhookTemplate(string(text)); err != nil {
+			return nil, err
+		}
+	}
+
+	headers := make(map[string]string)
+	for _, header := range o.headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("无效的请求头: %s (格式应为 名称: 值)", header)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	secret := o.secret
+	if secret == "" {
+		secret = os.Getenv("BING_WEBHOOK_SECRET")
+	}
+
+	notifiers := make([]*bingclient.WebhookNotifier, 0, len(o.urls))
+	for _, spec := range o.urls {
+		format, url := defaultFormat, strings.TrimSpace(spec)
+		if name, rest, ok := strings.Cut(url, "="); ok && !strings.Contains(name, "://") {
+			if format, err = bingclient.ParseWebhookFormat(name); err != nil {
+				return nil, err
+			}
+			url = rest
+		}
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("无效的 Webhook 地址: %s", spec)
+		}
+
+		notifier := bingclient.NewWebhookNotifier(url, format, logger)
+		notifier.Template = tmpl
+		notifier.Headers = headers
+		notifier.Secret = secret
+		notifier.Retries = o.retries
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
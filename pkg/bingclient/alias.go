@@ -0,0 +1,217 @@
+package bingclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LatestAliasName 是指向最新壁纸的别名（不含扩展名）
+const LatestAliasName = "latest"
+
+// MarketAliasName 返回指定市场的今日壁纸别名（不含扩展名），如 today-zh-CN
+func MarketAliasName(market string) string {
+	return "today-" + SanitizeFilename(market)
+}
+
+// IsAliasFile 判断文件名是否为 BingImageStorage 维护的别名
+func IsAliasFile(name string) bool {
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return base == LatestAliasName || strings.HasPrefix(base, "today-")
+}
+
+// aliasStateName 是记录各别名当前指向的图片日期的状态文件，保存在输出目录中
+// 别名是复制的文件或存储不是本地文件系统时，无法从链接目标得知日期，据此判断
+const aliasStateName = ".aliases"
+
+// updateAliases 在保存成功后更新指向该文件的别名
+// 只有当图片日期不早于别名当前指向的图片日期时才会更新，避免下载旧壁纸（如 -date、-from）时覆盖别名；
+// today-<market> 别名只指向当前正在展示的壁纸
+func (bis *BingImageStorage) updateAliases(imageData *ImageData, filePath string, data []byte, isImage bool) {
+	if !bis.LatestAlias && (bis.MarketAlias == "" || !isImage) {
+		return
+	}
+
+	bis.loadAliasState()
+	ext := filepath.Ext(filePath)
+	var aliases []string
+	if bis.LatestAlias {
+		aliases = append(aliases, LatestAliasName+ext)
+	}
+	if bis.MarketAlias != "" && isImage {
+		if IsImageCurrent(imageData, time.Now()) {
+			aliases = append(aliases, MarketAliasName(bis.MarketAlias)+ext)
+		} else {
+			bis.Logger.Debug("跳过别名更新: %s 不是当前展示的壁纸", imageData.Startdate)
+		}
+	}
+
+	changed := false
+	for _, alias := range aliases {
+		if current, ok := bis.aliasDate(alias); ok && imageData.Startdate < current {
+			bis.Logger.Debug("跳过别名 %s 的更新: %s 早于其当前指向的 %s", alias, imageData.Startdate, current)
+			continue
+		}
+
+		aliasPath := filepath.Join(bis.OutputDir, alias)
+		if err := bis.updateAlias(aliasPath, filePath, data); err != nil {
+			bis.Logger.Warning("更新别名 %s 失败: %v", alias, err)
+			continue
+		}
+		bis.aliasDates[alias] = imageData.Startdate
+		changed = true
+		bis.Logger.Debug("别名 %s 已指向: %s", alias, filePath)
+	}
+
+	if changed {
+		if err := bis.saveAliasState(); err != nil {
+			bis.Logger.Warning("保存别名状态失败: %v", err)
+		}
+	}
+}
+
+// aliasDate 返回别名当前指向的图片日期 (YYYYMMDD)
+// 优先从符号链接目标的文件名中提取，否则读取状态文件中的记录
+func (bis *BingImageStorage) aliasDate(alias string) (string, bool) {
+	if isFileStorage(bis.Storage) {
+		if target, err := os.Readlink(filepath.Join(bis.OutputDir, alias)); err == nil {
+			if date, ok := DateFromFilename(target); ok {
+				return date, true
+			}
+		}
+	}
+
+	date, ok := bis.aliasDates[alias]
+	return date, ok
+}
+
+// loadAliasState 首次使用时读取别名状态文件
+func (bis *BingImageStorage) loadAliasState() {
+	if bis.aliasDates != nil {
+		return
+	}
+	bis.aliasDates = make(map[string]string)
+
+	statePath := filepath.Join(bis.OutputDir, aliasStateName)
+	var data []byte
+	var err error
+	if loadable, ok := bis.Storage.(LoadableStorage); ok && !isFileStorage(bis.Storage) {
+		data, err = loadable.Load(statePath)
+	} else {
+		data, err = os.ReadFile(statePath)
+	}
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &bis.aliasDates); err != nil {
+		bis.Logger.Warning("解析别名状态文件 %s 失败: %v", statePath, err)
+		bis.aliasDates = make(map[string]string)
+	}
+}
+
+// saveAliasState 将各别名指向的图片日期写入状态文件
+func (bis *BingImageStorage) saveAliasState() error {
+	data, err := json.MarshalIndent(bis.aliasDates, "", "  ")
+	if err != nil {
+		return err
+	}
+	statePath := filepath.Join(bis.OutputDir, aliasStateName)
+	if isFileStorage(bis.Storage) {
+		return replaceWithCopy(statePath, data)
+	}
+	return bis.Storage.Save(data, statePath)
+}
+
+// updateAlias 原子地将别名指向目标文件
+// 文件系统存储优先使用相对路径的符号链接，不支持符号链接时退回到复制；
+// 其他存储直接保存一份副本
+func (bis *BingImageStorage) updateAlias(aliasPath, target string, data []byte) error {
+	if filepath.Clean(aliasPath) == filepath.Clean(target) {
+		return nil
+	}
+
+	if !isFileStorage(bis.Storage) {
+		if data == nil {
+			return fmt.Errorf("当前存储不支持从读取器创建别名")
+		}
+		return bis.Storage.Save(data, aliasPath)
+	}
+
+	err := replaceWithSymlink(aliasPath, target)
+	if err == nil {
+		return nil
+	}
+	bis.Logger.Debug("无法创建符号链接，改为复制文件: %v", err)
+
+	if data == nil {
+		data, err = os.ReadFile(target)
+		if err != nil {
+			return fmt.Errorf("读取文件失败: %v", err)
+		}
+	}
+	return replaceWithCopy(aliasPath, data)
+}
+
+// isFileStorage 判断存储最终是否写入本地文件系统，ManifestStorage 包装的存储会被展开
+func isFileStorage(storage Storage) bool {
+	for {
+		switch s := storage.(type) {
+		case *FileStorage:
+			return true
+		case *ManifestStorage:
+			storage = s.Backend
+		default:
+			return false
+		}
+	}
+}
+
+// replaceWithSymlink 创建临时符号链接后重命名覆盖别名
+func replaceWithSymlink(aliasPath, target string) error {
+	relTarget, err := filepath.Rel(filepath.Dir(aliasPath), target)
+	if err != nil {
+		relTarget = target
+	}
+
+	tmpPath := fmt.Sprintf("%s.tmp-%d", aliasPath, os.Getpid())
+	os.Remove(tmpPath)
+	if err := os.Symlink(relTarget, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, aliasPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// replaceWithCopy 写入临时文件后重命名覆盖别名
+func replaceWithCopy(aliasPath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(aliasPath), "."+filepath.Base(aliasPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, aliasPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换别名失败: %v", err)
+	}
+	return nil
+}
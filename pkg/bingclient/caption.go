@@ -0,0 +1,467 @@
+package bingclient
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// 常见系统中的中日韩字体，未指定字体或指定的字体缺少字形时依次尝试
+var systemCJKFonts = []string{
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/opentype/noto/NotoSansCJKsc-Regular.otf",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wqy-microhei/wqy-microhei.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-zenhei.ttc",
+	"/usr/share/fonts/truetype/droid/DroidSansFallbackFull.ttf",
+	"/System/Library/Fonts/PingFang.ttc",
+	"/System/Library/Fonts/STHeiti Medium.ttc",
+	"/Library/Fonts/Arial Unicode.ttf",
+	`C:\Windows\Fonts\msyh.ttc`,
+	`C:\Windows\Fonts\simhei.ttf`,
+	`C:\Windows\Fonts\meiryo.ttc`,
+	`C:\Windows\Fonts\malgun.ttf`,
+}
+
+// CaptionPosition 表示文字在图片上的位置
+type CaptionPosition int
+
+const (
+	// CaptionBottomLeft 左下角
+	CaptionBottomLeft CaptionPosition = iota
+	// CaptionBottomRight 右下角
+	CaptionBottomRight
+	// CaptionBottomCenter 底部居中
+	CaptionBottomCenter
+	// CaptionTopLeft 左上角
+	CaptionTopLeft
+	// CaptionTopRight 右上角
+	CaptionTopRight
+	// CaptionTopCenter 顶部居中
+	CaptionTopCenter
+)
+
+var captionPositionNames = map[CaptionPosition]string{
+	CaptionBottomLeft:   "bottom-left",
+	CaptionBottomRight:  "bottom-right",
+	CaptionBottomCenter: "bottom-center",
+	CaptionTopLeft:      "top-left",
+	CaptionTopRight:     "top-right",
+	CaptionTopCenter:    "top-center",
+}
+
+// String 返回位置名称
+func (p CaptionPosition) String() string {
+	if name, ok := captionPositionNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseCaptionPosition 根据名称解析文字位置，如 bottom-left
+func ParseCaptionPosition(name string) (CaptionPosition, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for position, positionName := range captionPositionNames {
+		if name == positionName {
+			return position, nil
+		}
+	}
+	return 0, fmt.Errorf("不支持的文字位置: %s (可选 bottom-left, bottom-right, bottom-center, top-left, top-right, top-center)", name)
+}
+
+// top 判断位置是否位于图片上部
+func (p CaptionPosition) top() bool {
+	return p == CaptionTopLeft || p == CaptionTopRight || p == CaptionTopCenter
+}
+
+// CaptionProcessor 在图片副本上绘制标题和版权信息
+type CaptionProcessor struct {
+	Position  CaptionPosition // 文字位置
+	FontPaths []string        // TTF/OTF/TTC 字体文件，按顺序回退，为空时使用内置字体和系统中日韩字体
+	FontSize  float64         // 标题字号（像素，按 1080 像素高度换算），版权信息为其 0.6 倍
+	TextColor color.Color     // 文字颜色
+	Shadow    bool            // 是否绘制文字阴影
+
+	// BackdropOpacity 是文字背后半透明底色的不透明度 (0-1)，0 表示不绘制
+	BackdropOpacity float64
+
+	Logger Logger // 日志记录器
+
+	once  sync.Once
+	fonts []*sfnt.Font
+	err   error
+}
+
+// NewCaptionProcessor 创建一个使用默认样式的文字处理器
+func NewCaptionProcessor(logger Logger) *CaptionProcessor {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	return &CaptionProcessor{
+		Position:        CaptionBottomLeft,
+		FontSize:        40,
+		TextColor:       color.White,
+		Shadow:          true,
+		BackdropOpacity: 0.4,
+		Logger:          logger,
+	}
+}
+
+// CaptionPath 返回文字图的保存路径，如 variants/20261018_布莱德湖_caption.jpg
+func CaptionPath(imagePath string) string {
+	return variantPath(imagePath, VariantDir, "caption")
+}
+
+// Process 在原图副本上绘制标题和版权信息
+func (c *CaptionProcessor) Process(src image.Image, imageData *ImageData, imagePath string) ([]*ImageVariant, error) {
+	fonts, err := c.loadFonts()
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(imageData.Title)
+	copyright := strings.TrimSpace(imageData.Copyright)
+	if title == "" && copyright == "" {
+		return nil, nil
+	}
+	if err := checkCoverage(fonts, title+copyright); err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	scale := float64(bounds.Dy()) / 1080
+	fontSize := c.FontSize
+	if fontSize <= 0 {
+		fontSize = 40
+	}
+	titleText, err := newTextRenderer(fonts, fontSize*scale)
+	if err != nil {
+		return nil, err
+	}
+	bodyText, err := newTextRenderer(fonts, fontSize*scale*0.6)
+	if err != nil {
+		return nil, err
+	}
+
+	margin := int(math.Round(48 * scale))
+	padding := int(math.Round(fontSize * scale * 0.4))
+	maxWidth := fixed.I(bounds.Dx()*3/5 - 2*padding)
+
+	// 排版: 标题在上，版权信息在下，超出宽度时自动换行
+	type line struct {
+		text     string
+		renderer *textRenderer
+	}
+	var lines []line
+	for _, text := range titleText.wrap(title, maxWidth) {
+		lines = append(lines, line{text, titleText})
+	}
+	for _, text := range bodyText.wrap(copyright, maxWidth) {
+		lines = append(lines, line{text, bodyText})
+	}
+
+	blockW, blockH := 0, 0
+	for _, l := range lines {
+		blockW = max(blockW, l.renderer.measure(l.text).Ceil())
+		blockH += l.renderer.lineHeight()
+	}
+
+	// 计算文字块位置
+	var x, y int
+	switch c.Position {
+	case CaptionBottomRight, CaptionTopRight:
+		x = bounds.Dx() - margin - padding - blockW
+	case CaptionBottomCenter, CaptionTopCenter:
+		x = (bounds.Dx() - blockW) / 2
+	default:
+		x = margin + padding
+	}
+	if c.Position.top() {
+		y = margin + padding
+	} else {
+		y = bounds.Dy() - margin - padding - blockH
+	}
+
+	if c.BackdropOpacity > 0 {
+		alpha := uint8(math.Round(math.Min(c.BackdropOpacity, 1) * 255))
+		backdrop := image.Rect(x-padding, y-padding, x+blockW+padding, y+blockH+padding)
+		draw.Draw(dst, backdrop, image.NewUniform(color.NRGBA{A: alpha}), image.Point{}, draw.Over)
+	}
+
+	textColor := c.TextColor
+	if textColor == nil {
+		textColor = color.White
+	}
+	shadowOffset := max(1, int(math.Round(fontSize*scale/20)))
+
+	lineY := y
+	for _, l := range lines {
+		lineX := x
+		switch c.Position {
+		case CaptionBottomRight, CaptionTopRight:
+			lineX = x + blockW - l.renderer.measure(l.text).Ceil()
+		case CaptionBottomCenter, CaptionTopCenter:
+			lineX = x + (blockW-l.renderer.measure(l.text).Ceil())/2
+		}
+		baseline := lineY + l.renderer.ascent()
+
+		if c.Shadow {
+			l.renderer.draw(dst, l.text, lineX+shadowOffset, baseline+shadowOffset, image.NewUniform(color.NRGBA{A: 160}))
+		}
+		l.renderer.draw(dst, l.text, lineX, baseline, image.NewUniform(textColor))
+		lineY += l.renderer.lineHeight()
+	}
+
+	return []*ImageVariant{{
+		Kind:  VariantCaption,
+		Name:  "caption",
+		Path:  CaptionPath(imagePath),
+		Image: dst,
+	}}, nil
+}
+
+// loadFonts 加载字体回退链: 指定的字体、内置 Go 字体、系统中日韩字体
+func (c *CaptionProcessor) loadFonts() ([]*sfnt.Font, error) {
+	c.once.Do(func() {
+		for _, path := range c.FontPaths {
+			f, err := LoadFontFile(path)
+			if err != nil {
+				c.err = err
+				return
+			}
+			c.fonts = append(c.fonts, f)
+		}
+
+		builtin, err := sfnt.Parse(gobold.TTF)
+		if err != nil {
+			c.err = fmt.Errorf("解析内置字体失败: %v", err)
+			return
+		}
+		c.fonts = append(c.fonts, builtin)
+
+		for _, path := range systemCJKFonts {
+			if !fileExists(path) {
+				continue
+			}
+			f, err := LoadFontFile(path)
+			if err != nil {
+				c.logger().Debug("跳过系统字体 %s: %v", path, err)
+				continue
+			}
+			c.logger().Debug("使用系统字体: %s", path)
+			c.fonts = append(c.fonts, f)
+			break
+		}
+	})
+	return c.fonts, c.err
+}
+
+// checkCoverage 检查字体是否包含文字中的全部字符
+// 缺少字形时返回错误而不是绘制方框，内置字体只包含西文字符，中日韩文字需要系统字体或 FontPaths
+func checkCoverage(fonts []*sfnt.Font, text string) error {
+	var buf sfnt.Buffer
+	for _, r := range text {
+		if unicode.IsSpace(r) || fontFor(fonts, &buf, r) != nil {
+			continue
+		}
+		return fmt.Errorf("字体中缺少字符 %q，内置字体只包含西文字符，请使用 -caption-font 指定支持该文字的字体（如 Noto Sans CJK）", r)
+	}
+	return nil
+}
+
+// SystemCJKFont 返回系统中可用的中日韩字体路径，没有找到时返回空字符串
+func SystemCJKFont() string {
+	for _, path := range systemCJKFonts {
+		if fileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// IsCJKLocale 判断区域设置是否使用中日韩文字，如 zh-CN、ja-JP、ko-KR
+func IsCJKLocale(locale string) bool {
+	lang := strings.ToLower(strings.SplitN(locale, "-", 2)[0])
+	return lang == "zh" || lang == "ja" || lang == "ko"
+}
+
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// logger 返回日志记录器
+func (c *CaptionProcessor) logger() Logger {
+	if c.Logger == nil {
+		return &NullLogger{}
+	}
+	return c.Logger
+}
+
+// LoadFontFile 加载 TTF、OTF 或 TTC 字体文件，TTC 使用其中的第一个字体
+func LoadFontFile(path string) (*sfnt.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取字体文件失败: %v", err)
+	}
+	collection, err := sfnt.ParseCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析字体文件 %s 失败: %v", path, err)
+	}
+	if collection.NumFonts() == 0 {
+		return nil, fmt.Errorf("字体文件 %s 中没有字体", path)
+	}
+	f, err := collection.Font(0)
+	if err != nil {
+		return nil, fmt.Errorf("解析字体文件 %s 失败: %v", path, err)
+	}
+	return f, nil
+}
+
+// fontFor 返回回退链中第一个包含该字符的字体
+func fontFor(fonts []*sfnt.Font, buf *sfnt.Buffer, r rune) *sfnt.Font {
+	for _, f := range fonts {
+		if index, err := f.GlyphIndex(buf, r); err == nil && index != 0 {
+			return f
+		}
+	}
+	return nil
+}
+
+// textRenderer 按字符在字体回退链中选择字形并绘制文字
+type textRenderer struct {
+	fonts []*sfnt.Font
+	faces []font.Face
+	buf   sfnt.Buffer
+}
+
+// newTextRenderer 为回退链中的每个字体创建指定字号的字形
+func newTextRenderer(fonts []*sfnt.Font, size float64) (*textRenderer, error) {
+	t := &textRenderer{fonts: fonts}
+	for _, f := range fonts {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    math.Max(size, 1),
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("创建字体失败: %v", err)
+		}
+		t.faces = append(t.faces, face)
+	}
+	return t, nil
+}
+
+// faceFor 返回包含该字符的字形，都不包含时使用第一个字体
+func (t *textRenderer) faceFor(r rune) font.Face {
+	for i, f := range t.fonts {
+		if index, err := f.GlyphIndex(&t.buf, r); err == nil && index != 0 {
+			return t.faces[i]
+		}
+	}
+	return t.faces[0]
+}
+
+// ascent 返回回退链中最大的上升高度（像素）
+func (t *textRenderer) ascent() int {
+	var ascent fixed.Int26_6
+	for _, face := range t.faces {
+		ascent = max(ascent, face.Metrics().Ascent)
+	}
+	return ascent.Ceil()
+}
+
+// lineHeight 返回行高（像素）
+func (t *textRenderer) lineHeight() int {
+	var height fixed.Int26_6
+	for _, face := range t.faces {
+		m := face.Metrics()
+		height = max(height, m.Ascent+m.Descent)
+	}
+	return height.Ceil() * 6 / 5
+}
+
+// measure 返回文字的宽度
+func (t *textRenderer) measure(text string) fixed.Int26_6 {
+	var width fixed.Int26_6
+	for _, r := range text {
+		advance, _ := t.faceFor(r).GlyphAdvance(r)
+		width += advance
+	}
+	return width
+}
+
+// draw 从基线位置 (x, y) 开始绘制文字
+func (t *textRenderer) draw(dst draw.Image, text string, x, y int, src image.Image) {
+	drawer := &font.Drawer{
+		Dst: dst,
+		Src: src,
+		Dot: fixed.P(x, y),
+	}
+	for _, r := range text {
+		drawer.Face = t.faceFor(r)
+		drawer.DrawString(string(r))
+	}
+}
+
+// wrap 按最大宽度换行，优先在空格处断开，中日韩文字可在任意字符间断开
+func (t *textRenderer) wrap(text string, maxWidth fixed.Int26_6) []string {
+	if text == "" {
+		return nil
+	}
+	if maxWidth <= 0 {
+		return []string{text}
+	}
+
+	var lines []string
+	var current []rune
+	lastSpace := -1
+	for _, r := range text {
+		current = append(current, r)
+		if unicode.IsSpace(r) {
+			lastSpace = len(current) - 1
+		}
+		if t.measure(string(current)) <= maxWidth || len(current) == 1 {
+			continue
+		}
+
+		// 超出宽度: 西文在最后一个空格处断开，否则在当前字符前断开
+		breakAt := len(current) - 1
+		if lastSpace > 0 && !isCJK(r) {
+			breakAt = lastSpace
+		}
+		lines = append(lines, strings.TrimSpace(string(current[:breakAt])))
+		current = []rune(strings.TrimLeftFunc(string(current[breakAt:]), unicode.IsSpace))
+		lastSpace = -1
+	}
+	if rest := strings.TrimSpace(string(current)); rest != "" {
+		lines = append(lines, rest)
+	}
+	return lines
+}
+
+// isCJK 判断字符是否为中日韩文字或全角标点
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
@@ -55,6 +55,8 @@ type Client struct {
 	highQuality bool          // 高清质量
 	logger      Logger        // 日志记录器
 	httpClient  *http.Client  // HTTP客户端
+	minWidth    int           // 图片最小宽度
+	minHeight   int           // 图片最小高度
 }
 
 // 创建新的客户端实例
@@ -67,6 +69,8 @@ func NewClient(options ...ClientOption) *Client {
 		locale:      "zh-CN",
 		highQuality: true,
 		logger:      NewLogger(), // 使用默认日志记录器
+		minWidth:    DefaultMinImageWidth,
+		minHeight:   DefaultMinImageHeight,
 	}
 
 	// 应用配置选项
@@ -124,15 +128,30 @@ func WithBaseURL(baseURL string) ClientOption {
 	}
 }
 
+// 设置图片最小尺寸选项，下载的图片小于该尺寸时视为无效，传入 0 表示不检查
+func WithMinImageSize(width, height int) ClientOption {
+	return func(c *Client) {
+		c.minWidth = width
+		c.minHeight = height
+	}
+}
+
 // 发送HTTP请求并返回响应体
 func (c *Client) sendRequest(method, url string) ([]byte, error) {
+	body, _, err := c.doRequest(method, url)
+	return body, err
+}
+
+// 发送HTTP请求并返回响应体和响应头
+// 响应体长度与 Content-Length 不一致时视为响应不完整
+func (c *Client) doRequest(method, url string) ([]byte, http.Header, error) {
 	c.logger.Debug("发送 %s 请求到 %s", method, url)
 
 	// 创建请求
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
 		c.logger.Error("创建请求失败: %v", err)
-		return nil, fmt.Errorf("创建请求失败: %v", err)
+		return nil, nil, fmt.Errorf("创建请求失败: %v", err)
 	}
 
 	// 设置请求头
@@ -142,24 +161,30 @@ func (c *Client) sendRequest(method, url string) ([]byte, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		c.logger.Error("请求失败: %v", err)
-		return nil, fmt.Errorf("请求失败: %v", err)
+		return nil, nil, fmt.Errorf("请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		c.logger.Error("HTTP错误状态码: %d", resp.StatusCode)
-		return nil, fmt.Errorf("HTTP错误状态码: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("HTTP错误状态码: %d", resp.StatusCode)
 	}
 
 	// 读取响应体
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		c.logger.Error("读取响应失败: %v", err)
-		return nil, fmt.Errorf("读取响应失败: %v", err)
+		return nil, nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	// 检查响应是否完整
+	if resp.ContentLength >= 0 && int64(len(body)) != resp.ContentLength {
+		c.logger.Error("响应不完整: 收到 %d 字节，Content-Length 为 %d", len(body), resp.ContentLength)
+		return nil, nil, fmt.Errorf("响应不完整: 收到 %d 字节，Content-Length 为 %d", len(body), resp.ContentLength)
 	}
 
 	c.logger.Debug("成功收到响应 (%d 字节)", len(body))
-	return body, nil
+	return body, resp.Header, nil
 }
 
 // GetBingImageURL 获取 Bing 图片的完整 URL
@@ -185,6 +210,19 @@ func (c *Client) GetLogger() Logger {
 	return c.logger
 }
 
+// GetLocale 返回客户端使用的语言区域（市场代码）
+func (c *Client) GetLocale() string {
+	return c.locale
+}
+
+// GetResolution 返回下载图片的分辨率标识
+func (c *Client) GetResolution() string {
+	if c.highQuality {
+		return "UHD"
+	}
+	return "1920x1080"
+}
+
 // parseImageResponse 解析 API 响应数据
 func (c *Client) parseImageResponse(data []byte) ([]ImageData, error) {
 	c.logger.Debug("正在解析 API 响应数据...")
@@ -220,12 +258,140 @@ func (c *Client) FetchImageData(daysAgo int) (*ImageData, error) {
 	return &images[0], nil
 }
 
+// NextRolloverTime 获取当前的壁纸，返回下一次切换壁纸的时刻 (UTC)
+// 可用于在新壁纸发布后立即检查，而不是按固定间隔轮询
+func (c *Client) NextRolloverTime() (time.Time, error) {
+	imageData, err := c.FetchImageData(0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return imageData.NextRolloverTime(time.Now())
+}
+
+// FetchImageDataByDate 获取指定日历日期的壁纸数据
+// 日期按客户端市场的时区计算，只使用 date 的年月日
+func (c *Client) FetchImageDataByDate(date time.Time) (*ImageData, error) {
+	images, err := c.FetchImageDataRange(date, date)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Debug("壁纸标题: %s", images[0].Title)
+	return &images[0], nil
+}
+
+// FetchImageDataRange 获取日期范围内（包含两端）的壁纸数据，按日期从新到旧排列
+// 日期按客户端市场的时区计算，超出 Bing 保留范围时返回错误
+func (c *Client) FetchImageDataRange(from, to time.Time) ([]ImageData, error) {
+	now := time.Now()
+	if CalendarDate(from) > CalendarDate(to) {
+		return nil, fmt.Errorf("开始日期 %s 晚于结束日期 %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	newest, err := CheckRetention(c.locale, to, now)
+	if err != nil {
+		return nil, err
+	}
+	oldest, err := CheckRetention(c.locale, from, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 本机与 Bing 的切换时刻可能略有差异，窗口前后各多请求一天并按日期筛选
+	start := newest - 1
+	if start < 0 {
+		start = 0
+	}
+	count := oldest - start + 2
+	if start+count > MaxRetentionDays {
+		count = MaxRetentionDays - start
+	}
+	images, err := c.fetchImageWindow(start, count)
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := CalendarDate(from), CalendarDate(to)
+	result := make([]ImageData, 0, oldest-newest+1)
+	for _, image := range images {
+		if image.Startdate >= first && image.Startdate <= last {
+			result = append(result, image)
+		}
+	}
+	if len(result) == 0 {
+		if first == last {
+			c.logger.Error("未找到 %s 的壁纸数据", first)
+			return nil, fmt.Errorf("未找到 %s 的壁纸数据", first)
+		}
+		c.logger.Error("未找到 %s 至 %s 的壁纸数据", first, last)
+		return nil, fmt.Errorf("未找到 %s 至 %s 的壁纸数据", first, last)
+	}
+
+	c.logger.Info("成功获取 %s 至 %s 的 %d 条壁纸数据", first, last, len(result))
+	return result, nil
+}
+
+// fetchImageWindow 获取从 daysAgo 开始的 count 天壁纸数据
+// API 单次最多返回 maxImagesPerRequest 条，超出时拆分为多次请求，并按日期去重
+func (c *Client) fetchImageWindow(daysAgo, count int) ([]ImageData, error) {
+	var images []ImageData
+	seen := make(map[string]bool)
+
+	for offset := daysAgo; offset < daysAgo+count; offset += maxImagesPerRequest {
+		idx, n := offset, daysAgo+count-offset
+		if n > maxImagesPerRequest {
+			n = maxImagesPerRequest
+		}
+		// idx 超过 7 时 API 返回的仍是从 7 天前开始的壁纸，改为请求 7 天前起的完整窗口，重复的日期会被跳过
+		if idx > maxImageIndex {
+			idx, n = maxImageIndex, maxImagesPerRequest
+		}
+
+		batch, err := c.fetchMultipleImageData(idx, n)
+		if err != nil {
+			// 已获取到部分数据时，较旧的窗口可能已超出 API 范围
+			if len(images) > 0 {
+				c.logger.Warning("获取 %d 天前起的壁纸数据失败: %v", offset, err)
+				break
+			}
+			return nil, err
+		}
+		for _, image := range batch {
+			if seen[image.Startdate] {
+				continue
+			}
+			seen[image.Startdate] = true
+			images = append(images, image)
+		}
+	}
+
+	return images, nil
+}
+
 // FetchRawImageData 获取原始图片数据
 func (c *Client) FetchRawImageData(imageData *ImageData) ([]byte, error) {
-	imageURL := c.GetBingImageURL(imageData)
+	return c.FetchImage(c.GetBingImageURL(imageData))
+}
+
+// FetchImage 下载指定 URL 的图片并校验
+// 依次检查 Content-Type、Content-Length 是否一致，并完整解码 JPEG 检查最小尺寸，校验失败时返回错误
+func (c *Client) FetchImage(imageURL string) ([]byte, error) {
 	c.logger.Info("获取图片数据: %s", imageURL)
 
-	return c.sendRequest("GET", imageURL)
+	body, header, err := c.doRequest("GET", imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkImageContentType(header.Get("Content-Type")); err != nil {
+		c.logger.Error("图片校验失败: %v", err)
+		return nil, fmt.Errorf("图片校验失败: %v", err)
+	}
+	if err := ValidateJPEG(body, c.minWidth, c.minHeight); err != nil {
+		c.logger.Error("图片校验失败: %v", err)
+		return nil, fmt.Errorf("图片校验失败: %v", err)
+	}
+
+	c.logger.Debug("图片校验通过 (%d 字节)", len(body))
+	return body, nil
 }
 
 // FetchRawJsonData 获取原始的 JSON 数据
@@ -237,10 +403,10 @@ func (c *Client) FetchRawJsonData(apiURL string) ([]byte, error) {
 
 // FetchMultipleImageData 获取多天的壁纸数据
 func (c *Client) FetchMultipleImageData(days int) ([]ImageData, error) {
-	if days <= 0 || days > 16 {
-		return nil, fmt.Errorf("days 必须在 1-16 之间，当前值: %d", days)
+	if days <= 0 || days > MaxRetentionDays {
+		return nil, fmt.Errorf("days 必须在 1-%d 之间，当前值: %d", MaxRetentionDays, days)
 	}
-	return c.fetchMultipleImageData(0, days)
+	return c.fetchImageWindow(0, days)
 }
 
 // fetchMultipleImageData 获取多天的壁纸数据
@@ -0,0 +1,101 @@
+package bingclient
+
+import (
+	"strings"
+)
+
+// CopyrightInfo 是从版权信息中解析出的结构化字段
+type CopyrightInfo struct {
+	Description string // 画面描述，如 布莱德湖
+	Location    string // 拍摄地点，如 斯洛文尼亚
+	Credit      string // 摄影师或作者
+	Agency      string // 图片机构，如 Getty Images
+}
+
+// 版权署名的起始标记，按优先级排列
+var copyrightCreditOpeners = []string{"(©", "（©", "( ©", "（ ©", "(Ⓒ", "（Ⓒ", "(c)", "©", "Ⓒ"}
+
+// 描述与地点之间的分隔符，覆盖中日韩全角标点和西文逗号
+var copyrightLocationSeparators = []string{"，", "、", "، ", ", ", ","}
+
+// ParseCopyright 解析 Bing 的版权信息
+// 例如 "布莱德湖，斯洛文尼亚 (© Jane Doe/Getty Images)" 解析为
+// 描述 "布莱德湖"、地点 "斯洛文尼亚"、作者 "Jane Doe"、机构 "Getty Images"
+func ParseCopyright(copyright string) CopyrightInfo {
+	var info CopyrightInfo
+
+	text := strings.TrimSpace(copyright)
+	if text == "" {
+		return info
+	}
+
+	// 1. 拆分署名部分
+	body, credit := splitCopyrightCredit(text)
+	info.Credit, info.Agency = splitCopyrightAgency(credit)
+
+	// 2. 拆分描述与地点，第一个分隔符之前为描述
+	body = strings.TrimSpace(body)
+	for _, sep := range copyrightLocationSeparators {
+		if i := strings.Index(body, sep); i >= 0 {
+			info.Description = strings.TrimSpace(body[:i])
+			info.Location = strings.TrimSpace(body[i+len(sep):])
+			break
+		}
+	}
+	if info.Description == "" {
+		info.Description = body
+		info.Location = ""
+	}
+
+	return info
+}
+
+// CreditLine 返回完整的署名，如 "Jane Doe/Getty Images"
+func (c CopyrightInfo) CreditLine() string {
+	switch {
+	case c.Credit != "" && c.Agency != "":
+		return c.Credit + "/" + c.Agency
+	case c.Credit != "":
+		return c.Credit
+	default:
+		return c.Agency
+	}
+}
+
+// splitCopyrightCredit 将版权信息拆分为正文和署名
+func splitCopyrightCredit(text string) (string, string) {
+	for _, opener := range copyrightCreditOpeners {
+		i := strings.LastIndex(text, opener)
+		if i < 0 {
+			continue
+		}
+		credit := strings.TrimSpace(text[i+len(opener):])
+		// 只去掉与起始括号对应的一个右括号，保留署名内部的括号，如 "(© Foo (Bar))"
+		if copyrightCreditBracketed(opener) {
+			for _, closer := range []string{")", "）"} {
+				if strings.HasSuffix(credit, closer) {
+					credit = strings.TrimSuffix(credit, closer)
+					break
+				}
+			}
+		}
+		return text[:i], strings.TrimSpace(credit)
+	}
+	return text, ""
+}
+
+// copyrightCreditBracketed 判断署名起始标记是否以括号开头，此时署名以一个右括号结尾
+// 全角与半角括号可能混用，如 "（© Foo)"
+func copyrightCreditBracketed(opener string) bool {
+	return opener != "(c)" && (strings.HasPrefix(opener, "(") || strings.HasPrefix(opener, "（"))
+}
+
+// splitCopyrightAgency 将署名拆分为作者和机构，没有机构时整个署名作为作者
+func splitCopyrightAgency(credit string) (string, string) {
+	for _, sep := range []string{"/", "／"} {
+		if i := strings.LastIndex(credit, sep); i >= 0 {
+			return strings.TrimSpace(credit[:i]), strings.TrimSpace(credit[i+len(sep):])
+		}
+	}
+	return credit, ""
+}
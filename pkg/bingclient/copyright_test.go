@@ -0,0 +1,102 @@
+package bingclient
+
+import "testing"
+
+func TestParseCopyright(t *testing.T) {
+	tests := []struct {
+		name      string
+		copyright string
+		want      CopyrightInfo
+	}{
+		{
+			name:      "zh-CN",
+			copyright: "布莱德湖，斯洛文尼亚 (© Jane Doe/Getty Images)",
+			want:      CopyrightInfo{Description: "布莱德湖", Location: "斯洛文尼亚", Credit: "Jane Doe", Agency: "Getty Images"},
+		},
+		{
+			name:      "zh-CN 全角括号",
+			copyright: "布莱德湖，斯洛文尼亚（© Jane Doe/Getty Images）",
+			want:      CopyrightInfo{Description: "布莱德湖", Location: "斯洛文尼亚", Credit: "Jane Doe", Agency: "Getty Images"},
+		},
+		{
+			name:      "zh-CN 全角左括号与半角右括号",
+			copyright: "西湖，中国杭州（© Li Lei/Shutterstock)",
+			want:      CopyrightInfo{Description: "西湖", Location: "中国杭州", Credit: "Li Lei", Agency: "Shutterstock"},
+		},
+		{
+			name:      "en-US",
+			copyright: "Lake Bled, Slovenia (© Jane Doe/Getty Images)",
+			want:      CopyrightInfo{Description: "Lake Bled", Location: "Slovenia", Credit: "Jane Doe", Agency: "Getty Images"},
+		},
+		{
+			name:      "en-US 多级地点",
+			copyright: "Bled Island, Lake Bled, Slovenia (© Jane Doe/Getty Images)",
+			want:      CopyrightInfo{Description: "Bled Island", Location: "Lake Bled, Slovenia", Credit: "Jane Doe", Agency: "Getty Images"},
+		},
+		{
+			name:      "ja-JP",
+			copyright: "ブレッド湖、スロベニア (© Jane Doe/Getty Images)",
+			want:      CopyrightInfo{Description: "ブレッド湖", Location: "スロベニア", Credit: "Jane Doe", Agency: "Getty Images"},
+		},
+		{
+			name:      "de-DE",
+			copyright: "Bleder See, Slowenien (© Jane Doe/Getty Images)",
+			want:      CopyrightInfo{Description: "Bleder See", Location: "Slowenien", Credit: "Jane Doe", Agency: "Getty Images"},
+		},
+		{
+			name:      "没有地点",
+			copyright: "Polarlichter über Tromsø (© Ola Nordmann/Alamy)",
+			want:      CopyrightInfo{Description: "Polarlichter über Tromsø", Credit: "Ola Nordmann", Agency: "Alamy"},
+		},
+		{
+			name:      "没有机构",
+			copyright: "Lake Bled, Slovenia (© Jane Doe)",
+			want:      CopyrightInfo{Description: "Lake Bled", Location: "Slovenia", Credit: "Jane Doe"},
+		},
+		{
+			name:      "署名内部的括号",
+			copyright: "Lake Bled, Slovenia (© Jane Doe (Studio)/Getty Images)",
+			want:      CopyrightInfo{Description: "Lake Bled", Location: "Slovenia", Credit: "Jane Doe (Studio)", Agency: "Getty Images"},
+		},
+		{
+			name:      "没有括号的版权符号",
+			copyright: "Lake Bled, Slovenia © Jane Doe",
+			want:      CopyrightInfo{Description: "Lake Bled", Location: "Slovenia", Credit: "Jane Doe"},
+		},
+		{
+			name:      "没有署名",
+			copyright: "Lake Bled, Slovenia",
+			want:      CopyrightInfo{Description: "Lake Bled", Location: "Slovenia"},
+		},
+		{
+			name:      "空字符串",
+			copyright: "",
+			want:      CopyrightInfo{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseCopyright(tt.copyright); got != tt.want {
+				t.Errorf("ParseCopyright(%q) = %+v, want %+v", tt.copyright, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyrightInfoCreditLine(t *testing.T) {
+	tests := []struct {
+		info CopyrightInfo
+		want string
+	}{
+		{CopyrightInfo{Credit: "Jane Doe", Agency: "Getty Images"}, "Jane Doe/Getty Images"},
+		{CopyrightInfo{Credit: "Jane Doe"}, "Jane Doe"},
+		{CopyrightInfo{Agency: "Getty Images"}, "Getty Images"},
+		{CopyrightInfo{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.info.CreditLine(); got != tt.want {
+			t.Errorf("%+v.CreditLine() = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
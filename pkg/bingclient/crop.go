@@ -0,0 +1,302 @@
+package bingclient
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// VariantDir 是裁剪图等派生图片所在的子目录（相对原图所在目录）
+const VariantDir = "variants"
+
+// 用于计算边缘能量的分析图宽度
+const cropAnalysisWidth = 192
+
+// Top/Bot 构图提示的权重，大于默认的居中权重，使提示在画面细节相近时生效
+const cropHintWeight = 0.4
+
+// CropMode 表示裁剪方式
+type CropMode int
+
+const (
+	// CropFill 铺满目标尺寸，根据构图提示和边缘能量选择保留的区域
+	CropFill CropMode = iota
+	// CropFitBlurBars 完整显示原图，空白部分使用模糊后的原图填充
+	CropFitBlurBars
+	// CropCenter 铺满目标尺寸，始终保留中间区域
+	CropCenter
+)
+
+// String 返回裁剪方式名称
+func (m CropMode) String() string {
+	switch m {
+	case CropFill:
+		return "fill"
+	case CropFitBlurBars:
+		return "fit-blur-bars"
+	case CropCenter:
+		return "center"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCropMode 根据名称解析裁剪方式
+func ParseCropMode(name string) (CropMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "fill", "":
+		return CropFill, nil
+	case "fit-blur-bars", "fit":
+		return CropFitBlurBars, nil
+	case "center":
+		return CropCenter, nil
+	default:
+		return 0, fmt.Errorf("不支持的裁剪方式: %s (可选 fill, fit-blur-bars, center)", name)
+	}
+}
+
+// Resolution 表示目标分辨率
+type Resolution struct {
+	Width  int // 宽度（像素）
+	Height int // 高度（像素）
+}
+
+// String 返回 WIDTHxHEIGHT 形式的分辨率
+func (r Resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// ParseResolution 解析 WIDTHxHEIGHT 形式的分辨率，如 3440x1440
+func ParseResolution(value string) (Resolution, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(value)), "x")
+	if len(parts) != 2 {
+		return Resolution{}, fmt.Errorf("无效的分辨率: %s (格式应为 宽x高)", value)
+	}
+	width, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	height, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return Resolution{}, fmt.Errorf("无效的分辨率: %s (格式应为 宽x高)", value)
+	}
+	return Resolution{Width: width, Height: height}, nil
+}
+
+// ParseResolutions 解析以逗号分隔的多个分辨率
+func ParseResolutions(value string) ([]Resolution, error) {
+	var resolutions []Resolution
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		resolution, err := ParseResolution(part)
+		if err != nil {
+			return nil, err
+		}
+		resolutions = append(resolutions, resolution)
+	}
+	return resolutions, nil
+}
+
+// CropProcessor 将壁纸裁剪或适配为精确的目标分辨率
+type CropProcessor struct {
+	Targets []Resolution // 目标分辨率
+	Mode    CropMode     // 裁剪方式
+
+	// CenterBias 是 fill 模式下居中的权重 (0-1)，越大越倾向于保留中间区域
+	CenterBias float64
+}
+
+// NewCropProcessor 创建一个裁剪处理器
+func NewCropProcessor(mode CropMode, targets ...Resolution) *CropProcessor {
+	return &CropProcessor{
+		Targets:    append([]Resolution(nil), targets...),
+		Mode:       mode,
+		CenterBias: 0.3,
+	}
+}
+
+// CropPath 返回裁剪图的保存路径，如 variants/20261018_布莱德湖_3440x1440_fill.jpg
+func CropPath(imagePath string, target Resolution, mode CropMode) string {
+	return variantPath(imagePath, VariantDir, fmt.Sprintf("%s_%s", target, mode))
+}
+
+// Process 为每个目标分辨率生成一张图片
+func (c *CropProcessor) Process(src image.Image, imageData *ImageData, imagePath string) ([]*ImageVariant, error) {
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("图片尺寸无效: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	variants := make([]*ImageVariant, 0, len(c.Targets))
+	for _, target := range c.Targets {
+		if target.Width <= 0 || target.Height <= 0 {
+			return nil, fmt.Errorf("无效的目标分辨率: %s", target)
+		}
+
+		var dst *image.RGBA
+		switch c.Mode {
+		case CropFitBlurBars:
+			dst = fitWithBlurBars(src, target)
+		case CropCenter:
+			dst = cropToFill(src, target, 0.5)
+		default:
+			dst = cropToFill(src, target, c.cropPosition(src, imageData, target))
+		}
+
+		variants = append(variants, &ImageVariant{
+			Kind:  VariantCrop,
+			Name:  fmt.Sprintf("%s_%s", target, c.Mode),
+			Path:  CropPath(imagePath, target, c.Mode),
+			Image: dst,
+		})
+	}
+	return variants, nil
+}
+
+// coverRect 返回原图中与目标宽高比一致的最大区域，position (0-1) 为裁剪轴上的位置
+func coverRect(bounds image.Rectangle, target Resolution, position float64) image.Rectangle {
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	position = math.Max(0, math.Min(1, position))
+
+	// 原图更宽时裁剪左右，否则裁剪上下
+	if srcW*target.Height > srcH*target.Width {
+		cropW := int(math.Round(float64(srcH) * float64(target.Width) / float64(target.Height)))
+		x := bounds.Min.X + int(math.Round(float64(srcW-cropW)*position))
+		return image.Rect(x, bounds.Min.Y, x+cropW, bounds.Max.Y)
+	}
+	cropH := int(math.Round(float64(srcW) * float64(target.Height) / float64(target.Width)))
+	y := bounds.Min.Y + int(math.Round(float64(srcH-cropH)*position))
+	return image.Rect(bounds.Min.X, y, bounds.Max.X, y+cropH)
+}
+
+// cropToFill 按目标宽高比裁剪后缩放到目标尺寸
+func cropToFill(src image.Image, target Resolution, position float64) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, target.Width, target.Height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, coverRect(src.Bounds(), target, position), draw.Src, nil)
+	return dst
+}
+
+// fitWithBlurBars 将完整的原图缩放到目标尺寸内，空白部分使用模糊并压暗的原图填充
+func fitWithBlurBars(src image.Image, target Resolution) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, target.Width, target.Height))
+	bounds := src.Bounds()
+
+	// 背景: 先缩小再放大得到模糊效果
+	smallW, smallH := max(1, target.Width/24), max(1, target.Height/24)
+	small := image.NewRGBA(image.Rect(0, 0, smallW, smallH))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), src, coverRect(bounds, target, 0.5), draw.Src, nil)
+	draw.BiLinear.Scale(dst, dst.Bounds(), small, small.Bounds(), draw.Src, nil)
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.RGBA{A: 96}), image.Point{}, draw.Over)
+
+	// 前景: 保持宽高比居中显示
+	scale := math.Min(float64(target.Width)/float64(bounds.Dx()), float64(target.Height)/float64(bounds.Dy()))
+	fitW := int(math.Round(float64(bounds.Dx()) * scale))
+	fitH := int(math.Round(float64(bounds.Dy()) * scale))
+	x := (target.Width - fitW) / 2
+	y := (target.Height - fitH) / 2
+	draw.CatmullRom.Scale(dst, image.Rect(x, y, x+fitW, y+fitH), src, bounds, draw.Src, nil)
+	return dst
+}
+
+// cropPosition 计算 fill 模式下裁剪轴上的最佳位置 (0-1)
+// 综合三个因素: 边缘能量（细节越多越值得保留）、居中偏好，以及 ImageData 的 Top/Bot 提示
+// Top 非零表示主体靠近上部，Bot 非零表示主体靠近下部，只在上下裁剪时生效
+func (c *CropProcessor) cropPosition(src image.Image, imageData *ImageData, target Resolution) float64 {
+	bounds := src.Bounds()
+	horizontal := bounds.Dx()*target.Height > bounds.Dy()*target.Width
+
+	// 在缩小的分析图上计算沿裁剪轴的能量分布
+	analysisW := min(cropAnalysisWidth, bounds.Dx())
+	analysisH := max(1, bounds.Dy()*analysisW/bounds.Dx())
+	analysis := image.NewRGBA(image.Rect(0, 0, analysisW, analysisH))
+	draw.ApproxBiLinear.Scale(analysis, analysis.Bounds(), src, bounds, draw.Src, nil)
+	profile := edgeEnergyProfile(analysis, horizontal)
+
+	// 裁剪窗口在分析图上的长度
+	window := coverRect(analysis.Bounds(), target, 0)
+	windowLen := window.Dy()
+	if horizontal {
+		windowLen = window.Dx()
+	}
+	steps := len(profile) - windowLen
+	if steps <= 0 {
+		return 0.5
+	}
+
+	var total float64
+	for _, v := range profile {
+		total += v
+	}
+
+	// 滑动窗口求能量和
+	var sum float64
+	for i := 0; i < windowLen; i++ {
+		sum += profile[i]
+	}
+
+	hint := 0.5
+	if !horizontal && imageData != nil {
+		switch {
+		case imageData.Top != 0 && imageData.Bot == 0:
+			hint = 0
+		case imageData.Bot != 0 && imageData.Top == 0:
+			hint = 1
+		}
+	}
+
+	best, bestScore := 0.5, math.Inf(-1)
+	for offset := 0; offset <= steps; offset++ {
+		if offset > 0 {
+			sum += profile[offset+windowLen-1] - profile[offset-1]
+		}
+		position := float64(offset) / float64(steps)
+
+		energy := 0.0
+		if total > 0 {
+			energy = sum / total
+		}
+		score := energy - c.CenterBias*math.Abs(position-0.5) - cropHintWeight*math.Abs(position-hint)
+		if score > bestScore {
+			best, bestScore = position, score
+		}
+	}
+	return best
+}
+
+// edgeEnergyProfile 计算亮度梯度幅值在每一列（horizontal）或每一行上的和
+func edgeEnergyProfile(img *image.RGBA, horizontal bool) []float64 {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	luma := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y)
+			r, g, b := img.Pix[i], img.Pix[i+1], img.Pix[i+2]
+			luma[y*w+x] = 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+		}
+	}
+
+	length := h
+	if horizontal {
+		length = w
+	}
+	profile := make([]float64, length)
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			gx := luma[y*w+x+1] - luma[y*w+x-1]
+			gy := luma[(y+1)*w+x] - luma[(y-1)*w+x]
+			energy := math.Abs(gx) + math.Abs(gy)
+			if horizontal {
+				profile[x] += energy
+			} else {
+				profile[y] += energy
+			}
+		}
+	}
+	return profile
+}
@@ -0,0 +1,207 @@
+package bingclient
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bing 日期字段的格式
+const (
+	bingDateLayout     = "20060102"
+	bingDateTimeLayout = "200601021504"
+)
+
+// MaxRetentionDays 是 Bing API 可获取的最多天数（包括今天）
+// API 的 idx 最大为 7，n 最大为 8，因此最多能获取 0 至 14 天前共 15 天的壁纸
+const MaxRetentionDays = 15
+
+// API 单次请求最多返回的壁纸数量
+const maxImagesPerRequest = 8
+
+// API 接受的最大 idx，更大的值会被当作 7 处理
+const maxImageIndex = 7
+
+// marketZones 记录各市场切换壁纸时使用的时区
+// Bing 在市场所在时区的零点切换壁纸，fullstartdate 即该时刻对应的 UTC 时间
+var marketZones = map[string]string{
+	"zh-CN": "Asia/Shanghai",
+	"zh-TW": "Asia/Taipei",
+	"zh-HK": "Asia/Hong_Kong",
+	"ja-JP": "Asia/Tokyo",
+	"ko-KR": "Asia/Seoul",
+	"en-IN": "Asia/Kolkata",
+	"en-US": "America/Los_Angeles",
+	"en-CA": "America/Toronto",
+	"fr-CA": "America/Toronto",
+	"pt-BR": "America/Sao_Paulo",
+	"en-GB": "Europe/London",
+	"de-DE": "Europe/Berlin",
+	"fr-FR": "Europe/Paris",
+	"es-ES": "Europe/Madrid",
+	"it-IT": "Europe/Rome",
+	"en-AU": "Australia/Sydney",
+	"en-NZ": "Pacific/Auckland",
+}
+
+// MarketLocation 返回市场对应的时区，未知市场或时区数据不可用时返回 UTC
+func MarketLocation(market string) *time.Location {
+	name, ok := marketZones[normalizeMarket(market)]
+	if !ok {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// normalizeMarket 将市场代码规范化为 zh-CN 形式
+func normalizeMarket(market string) string {
+	parts := strings.SplitN(strings.TrimSpace(market), "-", 2)
+	if len(parts) != 2 {
+		return strings.ToLower(market)
+	}
+	return strings.ToLower(parts[0]) + "-" + strings.ToUpper(parts[1])
+}
+
+// MarketToday 返回指定时刻在市场时区中的日期（当天零点）
+func MarketToday(market string, now time.Time) time.Time {
+	local := now.In(MarketLocation(market))
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
+
+// CalendarDate 返回 t 所在日历日期的 YYYYMMDD 字符串
+func CalendarDate(t time.Time) string {
+	return t.Format(bingDateLayout)
+}
+
+// ParseBingDate 解析 YYYYMMDD 格式的日期，返回指定时区中当天零点
+func ParseBingDate(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(bingDateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的日期格式: %s", value)
+	}
+	return t, nil
+}
+
+// Market 返回图片所属的市场，优先从图片 URL 中解析
+func (d *ImageData) Market() string {
+	return ParseImageMarket(d)
+}
+
+// StartTime 返回壁纸开始展示的时刻 (UTC)
+// 优先使用 fullstartdate，缺失时按市场时区的 startdate 零点计算
+func (d *ImageData) StartTime() (time.Time, error) {
+	if d.Fullstartdate != "" {
+		t, err := time.ParseInLocation(bingDateTimeLayout, d.Fullstartdate, time.UTC)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无效的完整日期时间格式: %s", d.Fullstartdate)
+		}
+		return t, nil
+	}
+
+	t, err := ParseBingDate(d.Startdate, MarketLocation(d.Market()))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// EndTime 返回壁纸停止展示的时刻 (UTC)
+// 按 startdate 与 enddate 相差的天数推算，通常为开始时刻之后 24 小时
+func (d *ImageData) EndTime() (time.Time, error) {
+	start, err := d.StartTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	days := 1
+	if d.Startdate != "" && d.Enddate != "" {
+		startDate, err := ParseBingDate(d.Startdate, time.UTC)
+		if err != nil {
+			return time.Time{}, err
+		}
+		endDate, err := ParseBingDate(d.Enddate, time.UTC)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if n := int(endDate.Sub(startDate).Hours() / 24); n > 0 {
+			days = n
+		}
+	}
+	return start.Add(time.Duration(days) * 24 * time.Hour), nil
+}
+
+// LocalStartTime 返回壁纸在其市场时区中的开始时刻
+func (d *ImageData) LocalStartTime() (time.Time, error) {
+	start, err := d.StartTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.In(MarketLocation(d.Market())), nil
+}
+
+// Date 返回壁纸所属的日历日期（市场时区中的当天零点）
+func (d *ImageData) Date() (time.Time, error) {
+	return ParseBingDate(d.Startdate, MarketLocation(d.Market()))
+}
+
+// IsImageCurrent 判断壁纸在指定时刻是否正在展示
+// 无法解析时间时退回到比较市场时区中的日期
+func IsImageCurrent(imageData *ImageData, now time.Time) bool {
+	start, err := imageData.StartTime()
+	if err == nil {
+		end, err := imageData.EndTime()
+		if err == nil {
+			return !now.Before(start) && now.Before(end)
+		}
+	}
+	return imageData.Startdate == CalendarDate(MarketToday(imageData.Market(), now))
+}
+
+// NextRolloverTime 返回 now 之后壁纸的下一次切换时刻 (UTC)
+// 壁纸仍在展示时即其停止展示的时刻；已经过了切换时刻（新壁纸尚未发布）时，按每 24 小时切换一次向后推算
+func (d *ImageData) NextRolloverTime(now time.Time) (time.Time, error) {
+	end, err := d.EndTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end.After(now) {
+		return end, nil
+	}
+	days := int(now.Sub(end)/(24*time.Hour)) + 1
+	return end.Add(time.Duration(days) * 24 * time.Hour), nil
+}
+
+// IsRolloverPending 判断壁纸是否已经过了切换时刻，即新的壁纸应当发布但还未获取到
+func IsRolloverPending(imageData *ImageData, now time.Time) bool {
+	end, err := imageData.EndTime()
+	return err == nil && !now.Before(end)
+}
+
+// DaysAgoForDate 返回指定日历日期相对于市场今天的天数
+// 仅使用 date 的年月日，与其所在时区无关
+func DaysAgoForDate(market string, date, now time.Time) int {
+	today := MarketToday(market, now)
+	target := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	todayUTC := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	return int(todayUTC.Sub(target).Hours() / 24)
+}
+
+// CheckRetention 检查日期是否在 Bing 的保留范围内，返回相对于市场今天的天数
+func CheckRetention(market string, date, now time.Time) (int, error) {
+	daysAgo := DaysAgoForDate(market, date, now)
+	if daysAgo >= 0 && daysAgo < MaxRetentionDays {
+		return daysAgo, nil
+	}
+
+	today := MarketToday(market, now)
+	oldest := today.AddDate(0, 0, -(MaxRetentionDays - 1))
+	if daysAgo < 0 {
+		return daysAgo, fmt.Errorf("日期 %s 晚于市场 %s 的今天 (%s)", date.Format("2006-01-02"), market, today.Format("2006-01-02"))
+	}
+	return daysAgo, fmt.Errorf("日期 %s 超出 Bing 的保留范围: 只能获取最近 %d 天 (%s 至 %s) 的壁纸",
+		date.Format("2006-01-02"), MaxRetentionDays, oldest.Format("2006-01-02"), today.Format("2006-01-02"))
+}
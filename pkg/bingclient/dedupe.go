@@ -0,0 +1,206 @@
+package bingclient
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math/bits"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// DefaultDuplicateThreshold 是判定为重复图片的默认最大汉明距离
+// 同一张照片重新编码或换用不同分辨率后，dHash 的距离通常不超过 4
+const DefaultDuplicateThreshold = 8
+
+// ImageHash 是 64 位感知哈希
+type ImageHash uint64
+
+// String 返回 16 位十六进制形式的哈希
+func (h ImageHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// Distance 返回两个哈希之间的汉明距离 (0-64)
+func (h ImageHash) Distance(other ImageHash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// ParseImageHash 解析 16 位十六进制形式的哈希
+func ParseImageHash(value string) (ImageHash, error) {
+	if len(value) != 16 {
+		return 0, fmt.Errorf("无效的感知哈希: %s", value)
+	}
+	v, err := strconv.ParseUint(value, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的感知哈希: %s", value)
+	}
+	return ImageHash(v), nil
+}
+
+// DifferenceHash 计算图片的 dHash
+// 将图片按区域平均缩小为 9x8 的灰度图，每一位表示同一行中相邻两格的亮度是否递增
+// 结果与分辨率和 JPEG 压缩质量基本无关，适合查找内容相同的壁纸
+func DifferenceHash(img image.Image) ImageHash {
+	const cols, rows = 9, 8
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return 0
+	}
+
+	// 按区域求平均亮度，大图按步长抽样以控制耗时
+	step := max(1, min(w, h)/256)
+	var grid [rows][cols]float64
+	for r := 0; r < rows; r++ {
+		y0, y1 := bounds.Min.Y+r*h/rows, bounds.Min.Y+(r+1)*h/rows
+		for c := 0; c < cols; c++ {
+			x0, x1 := bounds.Min.X+c*w/cols, bounds.Min.X+(c+1)*w/cols
+			var sum float64
+			var count int
+			for y := y0; y < max(y1, y0+1); y += step {
+				for x := x0; x < max(x1, x0+1); x += step {
+					cr, cg, cb, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(cr) + 0.587*float64(cg) + 0.114*float64(cb)
+					count++
+				}
+			}
+			grid[r][c] = sum / float64(count)
+		}
+	}
+
+	var hash ImageHash
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols-1; c++ {
+			hash <<= 1
+			if grid[r][c+1] > grid[r][c] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashAnalyzer 计算感知哈希并写入元数据
+type HashAnalyzer struct{}
+
+// NewHashAnalyzer 创建一个感知哈希分析器
+func NewHashAnalyzer() *HashAnalyzer {
+	return &HashAnalyzer{}
+}
+
+// Analyze 计算 dHash 并写入元数据的 DHash 字段
+func (a *HashAnalyzer) Analyze(src image.Image, meta *ImageMetadata) error {
+	if meta == nil {
+		return nil
+	}
+	meta.DHash = DifferenceHash(src).String()
+	return nil
+}
+
+// PerceptualHash 返回壁纸的感知哈希，优先使用元数据中记录的值，否则解码图片计算
+func (w *LocalWallpaper) PerceptualHash() (ImageHash, error) {
+	if w.Metadata != nil && w.Metadata.DHash != "" {
+		if hash, err := ParseImageHash(w.Metadata.DHash); err == nil {
+			return hash, nil
+		}
+	}
+
+	file, err := os.Open(w.Path)
+	if err != nil {
+		return 0, fmt.Errorf("打开图片失败: %v", err)
+	}
+	defer file.Close()
+
+	img, err := jpeg.Decode(file)
+	if err != nil {
+		return 0, fmt.Errorf("解码图片 %s 失败: %v", w.Path, err)
+	}
+	return DifferenceHash(img), nil
+}
+
+// DuplicateGroup 是一组内容相同或相近的壁纸
+type DuplicateGroup struct {
+	Keep       *LocalWallpaper   // 保留的壁纸（日期最早的一张）
+	Duplicates []*LocalWallpaper // 重复的壁纸
+	Distances  []int             // 每张重复壁纸与保留壁纸的汉明距离，经传递归入组内的壁纸可能超过阈值
+}
+
+// FindDuplicates 查找感知哈希距离不超过 threshold 的壁纸并分组
+// wallpapers 应按日期排序（见 ScanWallpapers），每组保留最早的一张
+// 距离具有传递性：A 与 B 相近、B 与 C 相近时三者归为一组，此时 C 与保留的 A 的距离可能超过 threshold，
+// 调用方在删除或硬链接前应检查 Distances
+// 无法计算哈希的壁纸会被跳过，错误一并返回
+func FindDuplicates(wallpapers []*LocalWallpaper, threshold int, logger Logger) ([]*DuplicateGroup, []error) {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+	if threshold < 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	var errs []error
+	hashed := make([]*LocalWallpaper, 0, len(wallpapers))
+	hashes := make([]ImageHash, 0, len(wallpapers))
+	for _, w := range wallpapers {
+		hash, err := w.PerceptualHash()
+		if err != nil {
+			logger.Warning("计算感知哈希失败: %v", err)
+			errs = append(errs, err)
+			continue
+		}
+		logger.Debug("%s: %s", w.Path, hash)
+		hashed = append(hashed, w)
+		hashes = append(hashes, hash)
+	}
+
+	// 并查集，根节点始终是下标最小（日期最早）的壁纸
+	parent := make([]int, len(hashed))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range hashed {
+		for j := i + 1; j < len(hashed); j++ {
+			if hashes[i].Distance(hashes[j]) > threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			if ri != rj {
+				parent[max(ri, rj)] = min(ri, rj)
+			}
+		}
+	}
+
+	groups := make(map[int]*DuplicateGroup)
+	var order []int
+	for i, w := range hashed {
+		root := find(i)
+		if root == i {
+			continue
+		}
+		group, ok := groups[root]
+		if !ok {
+			group = &DuplicateGroup{Keep: hashed[root]}
+			groups[root] = group
+			order = append(order, root)
+		}
+		group.Duplicates = append(group.Duplicates, w)
+		group.Distances = append(group.Distances, hashes[root].Distance(hashes[i]))
+	}
+
+	sort.Ints(order)
+	result := make([]*DuplicateGroup, 0, len(order))
+	for _, root := range order {
+		result = append(result, groups[root])
+	}
+	return result, errs
+}
@@ -7,20 +7,25 @@ import (
 
 // Downloader 是 Bing 壁纸下载器，协调 Client 与 Storage
 type Downloader struct {
-	Client       *Client           // API 客户端
-	Storage      *BingImageStorage // 存储工具
-	Logger       Logger            // 日志记录器
-	SaveJsonData bool              // 是否保存JSON数据
+	Client         *Client           // API 客户端
+	Storage        *BingImageStorage // 存储工具
+	Logger         Logger            // 日志记录器
+	SaveJsonData   bool              // 是否保存元数据文件
+	MetadataFormat MetadataFormat    // 元数据文件格式
+	Pipeline       *ImagePipeline    // 图片处理流水线，为 nil 时不生成派生图片
+	Hooks          []DownloadHook    // 新壁纸保存后依次调用的钩子
+	SkipExisting   bool              // 图片文件已存在时不再下载，用于定期运行时避免重复下载
 }
 
 // NewDownloader 创建新的壁纸下载器
 func NewDownloader(client *Client, storage *BingImageStorage) *Downloader {
 	// 使用 Client 的 GetLogger 方法获取日志记录器
 	return &Downloader{
-		Client:       client,
-		Storage:      storage,
-		Logger:       client.GetLogger(), // 通过方法获取 logger
-		SaveJsonData: true,               // 默认保存 JSON 数据
+		Client:         client,
+		Storage:        storage,
+		Logger:         client.GetLogger(), // 通过方法获取 logger
+		SaveJsonData:   true,               // 默认保存元数据
+		MetadataFormat: MetadataJSON,
 	}
 }
 
@@ -29,13 +34,45 @@ func (d *Downloader) SetLogger(logger Logger) {
 	d.Logger = logger
 }
 
+// AddHook 添加新壁纸保存后调用的钩子
+func (d *Downloader) AddHook(hook DownloadHook) *Downloader {
+	d.Hooks = append(d.Hooks, hook)
+	return d
+}
+
 // DownloadResult 壁纸下载结果
 type DownloadResult struct {
-	ImageData   ImageData // 图片元数据
-	ImagePath   string    // 图片保存路径
-	JsonPath    string    // JSON数据保存路径
-	DownloadErr error     // 下载错误
-	JsonErr     error     // JSON保存错误
+	ImageData   ImageData      // 图片元数据
+	Metadata    *ImageMetadata // 规范化元数据
+	ImagePath   string         // 图片保存路径
+	JsonPath    string         // 元数据文件保存路径
+	DownloadErr error          // 下载错误
+	JsonErr     error          // 元数据保存错误
+	Unchanged   bool           // 已保存的图片与下载的数据相同，跳过了写入
+	Skipped     bool           // 启用 SkipExisting 且图片已存在，没有下载，此时 Unchanged 也为 true
+
+	// HookErrs 记录钩子的执行错误，不影响壁纸的保存结果
+	HookErrs []error
+
+	// ThumbnailPaths 记录各宽度缩略图的保存路径
+	ThumbnailPaths map[int]string
+	// Variants 记录全部已保存的派生图片（缩略图、裁剪图等）
+	Variants []*SavedVariant
+	// ProcessErrs 记录图片处理过程中的错误，不影响原图的保存结果
+	ProcessErrs []error
+
+	// StorageErrs 记录各存储后端的保存错误
+	// 仅当存储实现了 BackendErrorReporter（如 MultiStorage）时才会填充
+	StorageErrs []*BackendError
+}
+
+// collectBackendErrors 收集存储后端在最近一次保存时的错误
+func (d *Downloader) collectBackendErrors(result *DownloadResult) {
+	reporter, ok := d.Storage.Storage.(BackendErrorReporter)
+	if !ok {
+		return
+	}
+	result.StorageErrs = append(result.StorageErrs, reporter.TakeBackendErrors()...)
 }
 
 // FetchAndSaveWallpaper 获取并保存单张壁纸
@@ -52,15 +89,62 @@ func (d *Downloader) FetchAndSaveWallpaper(daysAgo int) (*DownloadResult, error)
 	}
 
 	// 使用另一个方法处理图片数据
-	return d.SaveWallpaper(imageData, daysAgo)
+	return d.SaveWallpaperData(imageData)
+}
+
+// FetchByDate 获取并保存指定日历日期的壁纸
+// 日期按客户端市场的时区计算，超出 Bing 保留范围时返回错误
+func (d *Downloader) FetchByDate(date time.Time) (*DownloadResult, error) {
+	d.Logger.Info("===== 开始处理 %s 的壁纸 =====", date.Format("2006-01-02"))
+
+	imageData, err := d.Client.FetchImageDataByDate(date)
+	if err != nil {
+		d.Logger.Error("获取图片数据失败: %v", err)
+		return nil, fmt.Errorf("获取图片数据失败: %v", err)
+	}
+
+	return d.SaveWallpaperData(imageData)
+}
+
+// FetchRange 获取并保存日期范围内（包含两端）的壁纸
+// continueOnError 控制遇到错误时是否继续处理其他壁纸
+func (d *Downloader) FetchRange(from, to time.Time, continueOnError bool) ([]*DownloadResult, error) {
+	d.Logger.Info("正在获取 %s 至 %s 的壁纸", from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	imagesData, err := d.Client.FetchImageDataRange(from, to)
+	if err != nil {
+		d.Logger.Error("获取壁纸数据失败: %v", err)
+		return nil, err
+	}
+
+	return d.SaveWallpapers(imagesData, continueOnError)
 }
 
 // SaveWallpaper 保存单张壁纸
-// 当已有 ImageData 时，可直接调用此方法
+//
+// Deprecated: daysAgo 已不再使用，元数据直接由 ImageData 生成，请改用 SaveWallpaperData
 func (d *Downloader) SaveWallpaper(imageData *ImageData, daysAgo int) (*DownloadResult, error) {
+	return d.SaveWallpaperData(imageData)
+}
+
+// SaveWallpaperData 保存单张壁纸
+// 当已有 ImageData 时，可直接调用此方法，元数据直接由 ImageData 生成，不会再次请求 API
+func (d *Downloader) SaveWallpaperData(imageData *ImageData) (*DownloadResult, error) {
 	result := &DownloadResult{}
 	result.ImageData = *imageData
 
+	// 图片已存在时不再下载，也不重新生成元数据和派生图片
+	if d.SkipExisting {
+		imagePath := d.Storage.Generator.GenerateImageFilename(imageData, d.Storage.OutputDir)
+		if d.Storage.Storage.Exists(imagePath) {
+			d.Logger.Info("图片已存在，跳过下载: %s", imagePath)
+			result.ImagePath = imagePath
+			result.Unchanged = true
+			result.Skipped = true
+			return result, nil
+		}
+	}
+
 	// 1. 下载并保存图片
 	d.Logger.Info("下载并保存图片...")
 	imageBytes, err := d.Client.FetchRawImageData(imageData)
@@ -71,7 +155,8 @@ func (d *Downloader) SaveWallpaper(imageData *ImageData, daysAgo int) (*Download
 		return result, fmt.Errorf("图片下载失败: %v", err)
 	}
 
-	imagePath, err := d.Storage.SaveImage(imageBytes, imageData)
+	imagePath, savedBytes, unchanged, err := d.Storage.saveImage(imageBytes, imageData)
+	d.collectBackendErrors(result)
 	if err != nil {
 		result.DownloadErr = err
 		d.Logger.Warning("图片保存失败: %v", err)
@@ -79,30 +164,36 @@ func (d *Downloader) SaveWallpaper(imageData *ImageData, daysAgo int) (*Download
 	}
 
 	result.ImagePath = imagePath
-	d.Logger.Info("图片已保存到: %s", imagePath)
+	result.Unchanged = unchanged
+	if !unchanged {
+		d.Logger.Info("图片已保存到: %s", imagePath)
+	}
+
+	// 2. 生成规范化元数据，校验和基于实际保存的图片数据
+	result.Metadata = d.newImageMetadata(imageData, savedBytes)
 
-	// 2. 只有在启用 SaveJsonData 时才获取并保存 JSON 数据
+	// 3. 分析图片并生成派生图片
+	d.processImage(result, savedBytes, imageData)
+
+	// 4. 只有在启用 SaveJsonData 时才保存元数据文件
 	if d.SaveJsonData {
-		d.Logger.Info("下载并保存 JSON 数据...")
-		jsonBytes, err := d.Client.FetchRawJsonData(d.Client.GetBingApiURL(daysAgo, 1))
+		metaPath, err := d.Storage.SaveMetadata(result.Metadata, imageData, imagePath, d.MetadataFormat)
+		d.collectBackendErrors(result)
 		if err != nil {
 			result.JsonErr = err
-			d.Logger.Warning("JSON 数据获取失败: %v", err)
-			// 图片已成功保存，即使 JSON 失败也算基本成功，所以这里不返回错误
+			// 图片已成功保存，即使元数据失败也算基本成功，所以这里不返回错误
+			d.Logger.Warning("元数据保存失败: %v", err)
 		} else {
-			jsonPath, err := d.Storage.SaveJson(jsonBytes, imageData)
-			if err != nil {
-				result.JsonErr = err
-				d.Logger.Warning("JSON 数据保存失败: %v", err)
-			} else {
-				result.JsonPath = jsonPath
-				d.Logger.Info("JSON 数据已保存到: %s", jsonPath)
-			}
+			result.JsonPath = metaPath
+			d.Logger.Info("元数据已保存到: %s", metaPath)
 		}
 	} else {
-		d.Logger.Debug("跳过 JSON 数据保存（已禁用）")
+		d.Logger.Debug("跳过元数据保存（已禁用）")
 	}
 
+	// 5. 调用钩子，图片未变化时不调用
+	d.runHooks(result)
+
 	d.Logger.Info("===== 壁纸处理完成 =====")
 	return result, nil
 }
@@ -158,11 +249,8 @@ func (d *Downloader) SaveWallpapers(imageDataList []ImageData, continueOnError b
 
 	d.Logger.Info("开始处理 %d 张壁纸", len(imageDataList))
 
-	for i, imageData := range imageDataList {
-		// 为了找到正确的 daysAgo 值，我们假设列表是按照时间顺序排列的
-		daysAgo := i
-
-		result, err := d.SaveWallpaper(&imageData, daysAgo)
+	for i := range imageDataList {
+		result, err := d.SaveWallpaperData(&imageDataList[i])
 		if err != nil {
 			d.Logger.Error("处理第 %d 张壁纸失败: %v", i, err)
 			lastError = fmt.Errorf("处理第 %d 张壁纸失败: %v", i, err)
@@ -199,8 +287,8 @@ func (d *Downloader) SaveWallpapers(imageDataList []ImageData, continueOnError b
 // 这个方法会一次获取多天的数据，然后批量处理，减少 API 请求次数
 // continueOnError 控制遇到错误时是否继续处理其他壁纸
 func (d *Downloader) DownloadLatestWallpapers(days int, continueOnError bool) ([]*DownloadResult, error) {
-	if days <= 0 || days > 16 {
-		return nil, fmt.Errorf("days 必须在 1-16 之间，当前值: %d", days)
+	if days <= 0 || days > MaxRetentionDays {
+		return nil, fmt.Errorf("days 必须在 1-%d 之间，当前值: %d", MaxRetentionDays, days)
 	}
 
 	d.Logger.Info("正在批量获取最近 %d 天的壁纸", days)
@@ -215,3 +303,54 @@ func (d *Downloader) DownloadLatestWallpapers(days int, continueOnError bool) ([
 	// 2. 批量保存壁纸，使用传入的 continueOnError 参数
 	return d.SaveWallpapers(imagesData, continueOnError)
 }
+
+// newImageMetadata 生成规范化元数据，并补充客户端的市场和分辨率信息
+func (d *Downloader) newImageMetadata(imageData *ImageData, imageBytes []byte) *ImageMetadata {
+	meta := NewImageMetadata(imageData, imageBytes)
+	meta.URL = d.Client.GetBingImageURL(imageData)
+	meta.Resolution = d.Client.GetResolution()
+	if meta.Market == "" {
+		meta.Market = d.Client.GetLocale()
+	}
+	return meta
+}
+
+// processImage 运行图片处理流水线，分析结果写入元数据并记录派生图片
+func (d *Downloader) processImage(result *DownloadResult, data []byte, imageData *ImageData) {
+	if d.Pipeline == nil || d.Pipeline.Empty() {
+		return
+	}
+
+	d.Logger.Info("处理图片...")
+	variants, errs := d.Pipeline.Run(data, imageData, result.Metadata, result.ImagePath, d.Storage.Storage)
+	d.collectBackendErrors(result)
+	result.Variants = append(result.Variants, variants...)
+	result.ProcessErrs = append(result.ProcessErrs, errs...)
+
+	for _, variant := range variants {
+		if variant.Kind != VariantThumbnail {
+			continue
+		}
+		if result.ThumbnailPaths == nil {
+			result.ThumbnailPaths = make(map[int]string)
+		}
+		result.ThumbnailPaths[variant.Width] = variant.Path
+	}
+}
+
+// runHooks 对新保存的壁纸依次调用钩子，钩子的错误记录在结果中
+func (d *Downloader) runHooks(result *DownloadResult) {
+	if len(d.Hooks) == 0 {
+		return
+	}
+	if result.Unchanged || result.Skipped {
+		d.Logger.Debug("图片未变化，跳过钩子")
+		return
+	}
+	for _, hook := range d.Hooks {
+		if err := hook.OnNewWallpaper(result); err != nil {
+			d.Logger.Warning("钩子执行失败: %v", err)
+			result.HookErrs = append(result.HookErrs, err)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package bingclient
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"image/jpeg"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 邮件摘要的默认参数
+const (
+	DefaultDigestThumbnailWidth = 480             // 邮件中缩略图的默认宽度
+	DefaultSMTPTimeout          = 2 * time.Minute // 连接并发送一封邮件的默认超时时间
+)
+
+// SMTPSecurity 是 SMTP 连接的加密方式
+type SMTPSecurity int
+
+const (
+	// SMTPStartTLS 以明文连接后通过 STARTTLS 升级，服务器不支持时报错（通常使用 587 端口）
+	SMTPStartTLS SMTPSecurity = iota
+	// SMTPTLS 直接建立 TLS 连接（通常使用 465 端口）
+	SMTPTLS
+	// SMTPNone 不加密，只应用于本机或受信任网络中的中继
+	SMTPNone
+)
+
+// smtpSecurityNames 是加密方式的名称，与 SMTPSecurity 的取值一一对应
+var smtpSecurityNames = []string{"starttls", "tls", "none"}
+
+// String 返回加密方式的名称
+func (s SMTPSecurity) String() string {
+	if int(s) < len(smtpSecurityNames) {
+		return smtpSecurityNames[s]
+	}
+	return "unknown"
+}
+
+// ParseSMTPSecurity 解析加密方式名称
+func ParseSMTPSecurity(name string) (SMTPSecurity, error) {
+	for i, n := range smtpSecurityNames {
+		if strings.EqualFold(strings.TrimSpace(name), n) {
+			return SMTPSecurity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("不支持的 SMTP 加密方式: %s (可选 %s)", name, strings.Join(smtpSecurityNames, ", "))
+}
+
+// SMTPConfig 是 SMTP 服务器的配置
+type SMTPConfig struct {
+	Addr      string        // 服务器地址，如 smtp.example.com:587
+	Username  string        // 用户名，为空时不认证
+	Password  string        // 密码
+	Security  SMTPSecurity  // 加密方式
+	TLSConfig *tls.Config   // TLS 配置，为 nil 或未设置 ServerName 时按服务器主机名校验证书
+	Timeout   time.Duration // 超时时间，为 0 时使用 DefaultSMTPTimeout
+}
+
+// SelectDigest 返回日期不早于 since 的壁纸，保持原有顺序
+func SelectDigest(wallpapers []*LocalWallpaper, since time.Time) []*LocalWallpaper {
+	cutoff := since.Format("20060102")
+	var selected []*LocalWallpaper
+	for _, w := range wallpapers {
+		if date := w.Date(); date != "" && date >= cutoff {
+			selected = append(selected, w)
+		}
+	}
+	return selected
+}
+
+// EmailNotifier 将一段时间内的壁纸整理为摘要邮件，通过 SMTP 发送
+// 邮件为 multipart/alternative：纯文本版本，以及内嵌缩略图（cid:）的 HTML 版本
+type EmailNotifier struct {
+	SMTP           SMTPConfig // SMTP 服务器
+	From           string     // 发件人，如 "Bing 壁纸 <bing@example.com>"
+	To             []string   // 收件人
+	Subject        string     // 邮件主题
+	ThumbnailWidth int        // 缩略图宽度，为 0 时使用 DefaultDigestThumbnailWidth
+	Logger         Logger     // 日志记录器
+}
+
+// NewEmailNotifier 创建一个邮件通知
+func NewEmailNotifier(config SMTPConfig, from string, to []string, logger Logger) *EmailNotifier {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+	return &EmailNotifier{
+		SMTP:           config,
+		From:           from,
+		To:             to,
+		ThumbnailWidth: DefaultDigestThumbnailWidth,
+		Logger:         logger,
+	}
+}
+
+// SendDigest 生成并发送包含 wallpapers 的摘要邮件
+func (n *EmailNotifier) SendDigest(wallpapers []*LocalWallpaper) error {
+	message, err := n.Message(wallpapers, time.Now())
+	if err != nil {
+		return err
+	}
+	return n.Send(message)
+}
+
+// digestEntry 是摘要中的一张壁纸
+type digestEntry struct {
+	Title         string
+	Date          string
+	Copyright     string
+	CopyrightLink string
+	ContentID     string
+	Filename      string
+	Thumbnail     []byte
+	Width         int
+}
+
+// Src 返回 HTML 中引用内嵌缩略图的 cid: 地址
+// html/template 默认会过滤 http、https 和 mailto 以外的协议，因此需要显式标记为可信
+func (e *digestEntry) Src() template.URL {
+	return template.URL("cid:" + e.ContentID)
+}
+
+// digestHTML 是摘要邮件的 HTML 模板，使用内联样式以兼容常见的邮件客户端
+var digestHTML = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="margin:0;padding:16px;background:#f4f4f4;font-family:sans-serif;color:#222">
+<h1 style="font-size:20px">{{.Subject}}</h1>
+{{range .Entries}}<div style="margin:0 0 24px;padding:12px;background:#fff;border-radius:6px">
+<img src="{{.Src}}" width="{{.Width}}" alt="{{.Title}}" style="display:block;max-width:100%;height:auto">
+<h2 style="font-size:16px;margin:12px 0 4px">{{.Title}} <span style="font-weight:normal;color:#888">{{.Date}}</span></h2>
+<p style="margin:0;font-size:13px;color:#555">{{if .CopyrightLink}}<a href="{{.CopyrightLink}}" style="color:#555">{{.Copyright}}</a>{{else}}{{.Copyright}}{{end}}</p>
+</div>
+{{end}}</body></html>
+`))
+
+// Message 生成摘要邮件的完整内容（包括邮件头），now 用于 Date 头
+func (n *EmailNotifier) Message(wallpapers []*LocalWallpaper, now time.Time) ([]byte, error) {
+	if len(wallpapers) == 0 {
+		return nil, fmt.Errorf("没有可以发送的壁纸")
+	}
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return nil, fmt.Errorf("无效的发件人 %q: %v", n.From, err)
+	}
+	to, err := parseRecipients(n.To)
+	if err != nil {
+		return nil, err
+	}
+	width := n.ThumbnailWidth
+	if width <= 0 {
+		width = DefaultDigestThumbnailWidth
+	}
+
+	entries := make([]*digestEntry, 0, len(wallpapers))
+	for i, w := range wallpapers {
+		thumbnail, err := digestThumbnail(w.Path, width)
+		if err != nil {
+			n.Logger.Warning("跳过 %s: %v", w.Path, err)
+			continue
+		}
+		entry := &digestEntry{
+			Title:     strings.TrimSuffix(filepath.Base(w.Path), filepath.Ext(w.Path)),
+			Date:      w.Date(),
+			ContentID: fmt.Sprintf("wallpaper%d.%s@bingwallpaper", i+1, randomToken()),
+			Filename:  filepath.Base(w.Path),
+			Thumbnail: thumbnail,
+			Width:     width,
+		}
+		if config, err := jpeg.DecodeConfig(bytes.NewReader(thumbnail)); err == nil {
+			entry.Width = config.Width
+		}
+		if date, err := FormatDate(entry.Date); err == nil {
+			entry.Date = date
+		}
+		if meta := w.Metadata; meta != nil {
+			if meta.Title != "" {
+				entry.Title = meta.Title
+			}
+			entry.Copyright = meta.Copyright
+			entry.CopyrightLink = meta.CopyrightLink
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("没有可以发送的壁纸")
+	}
+
+	var buf bytes.Buffer
+	outer := multipart.NewWriter(&buf)
+	header := []string{
+		"From: " + from.String(),
+		"To: " + formatAddressList(to),
+		"Subject: " + mime.QEncoding.Encode("utf-8", n.Subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%d.%s@bingwallpaper>", now.UnixNano(), randomToken()),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", outer.Boundary()),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	// 纯文本版本
+	var text strings.Builder
+	text.WriteString(n.Subject + "\n\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&text, "%s  %s\n", entry.Date, entry.Title)
+		if entry.Copyright != "" {
+			text.WriteString(entry.Copyright + "\n")
+		}
+		if entry.CopyrightLink != "" {
+			text.WriteString(entry.CopyrightLink + "\n")
+		}
+		text.WriteString("\n")
+	}
+	if err := writeTextPart(outer, "text/plain", []byte(text.String())); err != nil {
+		return nil, err
+	}
+
+	// HTML 版本及其引用的缩略图组成 multipart/related
+	var relatedBody bytes.Buffer
+	related := multipart.NewWriter(&relatedBody)
+	var html bytes.Buffer
+	if err := digestHTML.Execute(&html, map[string]interface{}{"Subject": n.Subject, "Entries": entries}); err != nil {
+		return nil, fmt.Errorf("生成邮件正文失败: %v", err)
+	}
+	if err := writeTextPart(related, "text/html", html.Bytes()); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		partHeader := textproto.MIMEHeader{}
+		partHeader.Set("Content-Type", "image/jpeg")
+		partHeader.Set("Content-Transfer-Encoding", "base64")
+		partHeader.Set("Content-ID", "<"+entry.ContentID+">")
+		partHeader.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": entry.Filename}))
+		part, err := related.CreatePart(partHeader)
+		if err != nil {
+			return nil, fmt.Errorf("生成邮件失败: %v", err)
+		}
+		if err := writeBase64Lines(part, entry.Thumbnail); err != nil {
+			return nil, fmt.Errorf("生成邮件失败: %v", err)
+		}
+	}
+	if err := related.Close(); err != nil {
+		return nil, fmt.Errorf("生成邮件失败: %v", err)
+	}
+
+	relatedHeader := textproto.MIMEHeader{}
+	relatedHeader.Set("Content-Type", fmt.Sprintf("multipart/related; boundary=%q; type=\"text/html\"", related.Boundary()))
+	relatedPart, err := outer.CreatePart(relatedHeader)
+	if err != nil {
+		return nil, fmt.Errorf("生成邮件失败: %v", err)
+	}
+	if _, err := relatedPart.Write(relatedBody.Bytes()); err != nil {
+		return nil, fmt.Errorf("生成邮件失败: %v", err)
+	}
+	if err := outer.Close(); err != nil {
+		return nil, fmt.Errorf("生成邮件失败: %v", err)
+	}
+
+	n.Logger.Debug("摘要邮件包含 %d 张壁纸，共 %s", len(entries), FormatByteSize(int64(buf.Len())))
+	return buf.Bytes(), nil
+}
+
+// Send 通过 SMTP 服务器发送一封已生成的邮件
+func (n *EmailNotifier) Send(message []byte) error {
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return fmt.Errorf("无效的发件人 %q: %v", n.From, err)
+	}
+	to, err := parseRecipients(n.To)
+	if err != nil {
+		return err
+	}
+
+	config := n.SMTP
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		return fmt.Errorf("无效的 SMTP 服务器地址 %q: %v", config.Addr, err)
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+	tlsConfig := config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	} else if tlsConfig.ServerName == "" {
+		// tls.Dial 会自动填写 ServerName，STARTTLS 不会
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if config.Security == SMTPTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", config.Addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", config.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	if config.Security == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP 服务器 %s 不支持 STARTTLS", config.Addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %v", err)
+		}
+	}
+	if config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP 服务器 %s 不支持认证", config.Addr)
+		}
+		// PlainAuth 拒绝在未加密的连接上发送密码（本机地址除外）
+		if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %v", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP 服务器拒绝发件人 %s: %v", from.Address, err)
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("SMTP 服务器拒绝收件人 %s: %v", addr.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := client.Quit(); err != nil {
+		n.Logger.Debug("关闭 SMTP 连接失败: %v", err)
+	}
+	n.Logger.Info("已通过 %s 发送邮件给 %d 位收件人", config.Addr, len(to))
+	return nil
+}
+
+// digestThumbnail 返回邮件中使用的缩略图：优先使用已生成的同宽缩略图，否则解码原图后缩放
+func digestThumbnail(imagePath string, width int) ([]byte, error) {
+	if data, err := os.ReadFile(ThumbnailPath(imagePath, width)); err == nil {
+		return data, nil
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开图片失败: %v", err)
+	}
+	defer file.Close()
+	src, err := jpeg.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %v", err)
+	}
+
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("图片尺寸无效: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if width > bounds.Dx() {
+		width = bounds.Dx()
+	}
+	height := max((bounds.Dy()*width+bounds.Dx()/2)/bounds.Dx(), 1)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resizeImage(src, width, height), &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("编码缩略图失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseRecipients 解析收件人地址
+func parseRecipients(recipients []string) ([]*mail.Address, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("缺少收件人")
+	}
+	addrs := make([]*mail.Address, 0, len(recipients))
+	for _, r := range recipients {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			return nil, fmt.Errorf("无效的收件人 %q: %v", r, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// formatAddressList 将地址格式化为邮件头中以逗号分隔的列表
+func formatAddressList(addrs []*mail.Address) string {
+	parts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		parts[i] = addr.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeTextPart 写入一个 UTF-8 编码、quoted-printable 传输编码的文本部分
+func writeTextPart(w *multipart.Writer, contentType string, body []byte) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("生成邮件失败: %v", err)
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write(body); err != nil {
+		return fmt.Errorf("生成邮件失败: %v", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("生成邮件失败: %v", err)
+	}
+	return nil
+}
+
+// writeBase64Lines 以每行 76 个字符写入 base64 编码的数据（RFC 2045）
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(len(encoded), 76)
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// randomToken 返回用于 Message-ID 和 Content-ID 的随机字符串
+func randomToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
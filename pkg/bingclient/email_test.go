@@ -0,0 +1,539 @@
+package bingclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession 是假 SMTP 服务器记录的一次会话
+type smtpSession struct {
+	Commands []string // 客户端发送的命令，AUTH 的凭据已解码
+	Secure   []bool   // 每条命令是否在加密连接上收到
+	Data     string   // DATA 阶段收到的邮件内容
+}
+
+// securedCommand 返回命令在会话中是否只在加密连接上出现
+func (s *smtpSession) securedCommand(prefix string) bool {
+	found := false
+	for i, cmd := range s.Commands {
+		if strings.HasPrefix(cmd, prefix) {
+			if !s.Secure[i] {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// testCertificate 生成对 127.0.0.1 和 127.0.0.2 有效的自签名证书，并返回信任它的客户端配置
+func testCertificate(t *testing.T) (tls.Certificate, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bingwallpaper test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.2")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, &tls.Config{RootCAs: pool}
+}
+
+// fakeSMTPServer 在 host 上启动只处理一个连接的假 SMTP 服务器
+// mode 为 starttls（支持 STARTTLS）、tls（直接 TLS）或 none（不支持加密），返回服务器地址、
+// 客户端使用的 TLS 配置，以及等待会话结束并返回记录的函数
+func fakeSMTPServer(t *testing.T, host, mode string) (string, *tls.Config, func() *smtpSession) {
+	t.Helper()
+	cert, clientConfig := testCertificate(t)
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	var ln net.Listener
+	var err error
+	if mode == "tls" {
+		ln, err = tls.Listen("tcp", net.JoinHostPort(host, "0"), serverConfig)
+	} else {
+		ln, err = net.Listen("tcp", net.JoinHostPort(host, "0"))
+	}
+	if err != nil {
+		t.Skipf("无法监听 %s: %v", host, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	done := make(chan *smtpSession, 1)
+	go func() {
+		session := &smtpSession{}
+		defer func() { done <- session }()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		serveSMTP(conn, serverConfig, mode, session)
+	}()
+
+	return ln.Addr().String(), clientConfig, func() *smtpSession {
+		select {
+		case session := <-done:
+			return session
+		case <-time.After(10 * time.Second):
+			t.Fatal("等待 SMTP 会话结束超时")
+			return nil
+		}
+	}
+}
+
+// serveSMTP 处理一个 SMTP 连接，将收到的命令记录到 session
+func serveSMTP(conn net.Conn, config *tls.Config, mode string, session *smtpSession) {
+	secure := mode == "tls"
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) { fmt.Fprintf(conn, format+"\r\n", args...) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			reply("500 empty command")
+			continue
+		}
+		verb := strings.ToUpper(fields[0])
+		if verb == "AUTH" && len(fields) == 3 {
+			credentials, _ := base64.StdEncoding.DecodeString(fields[2])
+			line = fmt.Sprintf("AUTH %s %q", fields[1], credentials)
+		}
+		session.Commands = append(session.Commands, line)
+		session.Secure = append(session.Secure, secure)
+
+		switch verb {
+		case "EHLO":
+			reply("250-fake")
+			if mode == "starttls" && !secure {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, config)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r, secure = tlsConn, bufio.NewReader(tlsConn), true
+		case "AUTH":
+			if line == `AUTH PLAIN "\x00user\x00pass"` {
+				reply("235 authenticated")
+			} else {
+				reply("535 bad credentials")
+			}
+		case "MAIL", "RCPT":
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			session.Data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// testEmailMessage 是发送测试使用的邮件内容
+const testEmailMessage = "Subject: test\r\n\r\nhello\r\n.leading dot\r\n"
+
+func TestEmailNotifierSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		mode     string // 假服务器的模式
+		security SMTPSecurity
+		username string
+		want     []string // 期望的命令，为 nil 表示应返回错误
+		wantErr  string   // 期望错误中包含的内容
+		secure   bool     // AUTH、MAIL 和 DATA 是否必须在加密连接上发送
+	}{
+		{
+			name:     "STARTTLS 后认证并发送",
+			host:     "127.0.0.1",
+			mode:     "starttls",
+			security: SMTPStartTLS,
+			username: "user",
+			want: []string{
+				"EHLO localhost",
+				"STARTTLS",
+				"EHLO localhost",
+				`AUTH PLAIN "\x00user\x00pass"`,
+				"MAIL FROM:<bing@example.com>",
+				"RCPT TO:<alice@example.com>",
+				"RCPT TO:<bob@example.com>",
+				"DATA",
+				"QUIT",
+			},
+			secure: true,
+		},
+		{
+			name:     "直接 TLS 连接",
+			host:     "127.0.0.1",
+			mode:     "tls",
+			security: SMTPTLS,
+			username: "user",
+			want: []string{
+				"EHLO localhost",
+				`AUTH PLAIN "\x00user\x00pass"`,
+				"MAIL FROM:<bing@example.com>",
+				"RCPT TO:<alice@example.com>",
+				"RCPT TO:<bob@example.com>",
+				"DATA",
+				"QUIT",
+			},
+			secure: true,
+		},
+		{
+			name:     "不加密且不认证",
+			host:     "127.0.0.1",
+			mode:     "none",
+			security: SMTPNone,
+			want: []string{
+				"EHLO localhost",
+				"MAIL FROM:<bing@example.com>",
+				"RCPT TO:<alice@example.com>",
+				"RCPT TO:<bob@example.com>",
+				"DATA",
+				"QUIT",
+			},
+		},
+		{
+			name:     "starttls 模式下服务器不支持 STARTTLS",
+			host:     "127.0.0.1",
+			mode:     "none",
+			security: SMTPStartTLS,
+			username: "user",
+			wantErr:  "不支持 STARTTLS",
+		},
+		{
+			// 127.0.0.2 不是 PlainAuth 视为本机的地址，密码不能以明文发送
+			name:     "未加密的连接上拒绝认证",
+			host:     "127.0.0.2",
+			mode:     "none",
+			security: SMTPNone,
+			username: "user",
+			wantErr:  "SMTP 认证失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, tlsConfig, session := fakeSMTPServer(t, tt.host, tt.mode)
+			notifier := NewEmailNotifier(SMTPConfig{
+				Addr:      addr,
+				Username:  tt.username,
+				Password:  "pass",
+				Security:  tt.security,
+				TLSConfig: tlsConfig,
+				Timeout:   10 * time.Second,
+			}, "Bing 壁纸 <bing@example.com>", []string{"alice@example.com", "Bob <bob@example.com>"}, nil)
+
+			err := notifier.Send([]byte(testEmailMessage))
+			got := session()
+			if tt.want == nil {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("应返回包含 %q 的错误，实际为 %v", tt.wantErr, err)
+				}
+				for _, cmd := range got.Commands {
+					if strings.HasPrefix(cmd, "AUTH") || strings.HasPrefix(cmd, "MAIL") {
+						t.Errorf("出错后不应发送 %s", cmd)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Commands, tt.want) {
+				t.Errorf("命令\n got: %q\nwant: %q", got.Commands, tt.want)
+			}
+			if got.Data != testEmailMessage {
+				t.Errorf("邮件内容\n got: %q\nwant: %q", got.Data, testEmailMessage)
+			}
+			if tt.secure {
+				for _, prefix := range []string{"AUTH", "MAIL", "DATA"} {
+					if !got.securedCommand(prefix) {
+						t.Errorf("%s 应在加密连接上发送", prefix)
+					}
+				}
+			}
+		})
+	}
+}
+
+// writeTestJPEG 在 dir 中写入一张纯色的 JPEG 图片并返回其路径
+func writeTestJPEG(t *testing.T, dir, name string, width, height int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readPart 读取并返回部分的内容，multipart.Reader 会自动解码 quoted-printable
+func readPart(t *testing.T, part *multipart.Part) string {
+	t.Helper()
+	data, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// nextPart 返回下一个部分，并检查其媒体类型
+func nextPart(t *testing.T, r *multipart.Reader, wantType string) (*multipart.Part, map[string]string) {
+	t.Helper()
+	part, err := r.NextPart()
+	if err != nil {
+		t.Fatalf("读取 %s 部分失败: %v", wantType, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != wantType {
+		t.Fatalf("部分的类型为 %s, want %s", mediaType, wantType)
+	}
+	return part, params
+}
+
+func TestEmailNotifierMessage(t *testing.T) {
+	dir := t.TempDir()
+	wallpapers := []*LocalWallpaper{
+		{
+			Path: writeTestJPEG(t, dir, "20261001_zh-CN.jpg", 64, 36),
+			Metadata: &ImageMetadata{
+				StartDate:     "20261001",
+				Title:         "Bled <Island> & Lake",
+				Copyright:     "Lake Bled, Slovenia (© Jane Doe/Getty Images)",
+				CopyrightLink: "https://www.bing.com/search?q=Lake+Bled",
+			},
+		},
+		{Path: filepath.Join(dir, "20261002_zh-CN.jpg")}, // 文件不存在，应被跳过
+		{Path: writeTestJPEG(t, dir, "20261003_en-US.jpg", 32, 18)},
+	}
+
+	notifier := NewEmailNotifier(SMTPConfig{}, "Bing 壁纸 <bing@example.com>", []string{"alice@example.com", "Bob <bob@example.com>"}, nil)
+	notifier.Subject = "Bing 壁纸摘要 2026-10"
+	now := time.Date(2026, 10, 7, 8, 0, 0, 0, time.UTC)
+	data, err := notifier.Message(wallpapers, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 邮件头
+	rawSubject := msg.Header.Get("Subject")
+	if !strings.HasPrefix(rawSubject, "=?utf-8?q?") {
+		t.Errorf("Subject 应使用 Q 编码: %q", rawSubject)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(rawSubject); err != nil || subject != notifier.Subject {
+		t.Errorf("Subject 解码为 %q (%v), want %q", subject, err, notifier.Subject)
+	}
+	if from, err := msg.Header.AddressList("From"); err != nil || len(from) != 1 || from[0].Name != "Bing 壁纸" || from[0].Address != "bing@example.com" {
+		t.Errorf("From = %q (%v)", msg.Header.Get("From"), err)
+	}
+	to, err := msg.Header.AddressList("To")
+	if err != nil || len(to) != 2 || to[0].Address != "alice@example.com" || to[1].Address != "bob@example.com" {
+		t.Errorf("To = %q (%v)", msg.Header.Get("To"), err)
+	}
+	if date, err := msg.Header.Date(); err != nil || !date.Equal(now) {
+		t.Errorf("Date = %q (%v)", msg.Header.Get("Date"), err)
+	}
+
+	// multipart/alternative：纯文本，然后是 multipart/related
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	alternative := multipart.NewReader(msg.Body, params["boundary"])
+
+	textPart, _ := nextPart(t, alternative, "text/plain")
+	text := readPart(t, textPart)
+	for _, want := range []string{notifier.Subject, "2026年10月01日  Bled <Island> & Lake", "Lake Bled, Slovenia (© Jane Doe/Getty Images)", "2026年10月03日  20261003_en-US"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("纯文本中缺少 %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "20261002") {
+		t.Errorf("纯文本中不应包含无法读取的壁纸:\n%s", text)
+	}
+
+	relatedPart, relatedParams := nextPart(t, alternative, "multipart/related")
+	if relatedParams["type"] != "text/html" {
+		t.Errorf("multipart/related 的 type = %q", relatedParams["type"])
+	}
+	related := multipart.NewReader(relatedPart, relatedParams["boundary"])
+
+	htmlPart, _ := nextPart(t, related, "text/html")
+	html := readPart(t, htmlPart)
+	if !strings.Contains(html, "Bled &lt;Island&gt; &amp; Lake") {
+		t.Errorf("HTML 中的标题应被转义:\n%s", html)
+	}
+	var cids []string
+	for _, match := range regexp.MustCompile(`src="cid:([^"]+)"`).FindAllStringSubmatch(html, -1) {
+		cids = append(cids, match[1])
+	}
+
+	var contentIDs []string
+	widths := []int{64, 32}
+	for {
+		part, err := related.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("内嵌图片的 Content-Type = %q", ct)
+		}
+		contentIDs = append(contentIDs, strings.Trim(part.Header.Get("Content-ID"), "<>"))
+		thumbnail, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := jpeg.DecodeConfig(bytes.NewReader(thumbnail))
+		if err != nil {
+			t.Fatalf("内嵌图片不是有效的 JPEG: %v", err)
+		}
+		if i := len(contentIDs) - 1; i < len(widths) && config.Width != widths[i] {
+			t.Errorf("第 %d 张图片宽度为 %d, want %d", i+1, config.Width, widths[i])
+		}
+	}
+	if len(cids) != 2 || !reflect.DeepEqual(cids, contentIDs) {
+		t.Errorf("HTML 引用的 cid: %q，内嵌图片的 Content-ID: %q", cids, contentIDs)
+	}
+
+	if _, err := alternative.NextPart(); err != io.EOF {
+		t.Errorf("multipart/alternative 应只有两个部分: %v", err)
+	}
+}
+
+func TestEmailNotifierMessageErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := []*LocalWallpaper{{Path: filepath.Join(dir, "20261001_zh-CN.jpg")}}
+	valid := []*LocalWallpaper{{Path: writeTestJPEG(t, dir, "20261002_zh-CN.jpg", 16, 9)}}
+
+	tests := []struct {
+		name       string
+		from       string
+		to         []string
+		wallpapers []*LocalWallpaper
+	}{
+		{name: "没有壁纸", from: "bing@example.com", to: []string{"alice@example.com"}},
+		{name: "全部壁纸都无法读取", from: "bing@example.com", to: []string{"alice@example.com"}, wallpapers: missing},
+		{name: "无效的发件人", from: "bing", to: []string{"alice@example.com"}, wallpapers: valid},
+		{name: "缺少收件人", from: "bing@example.com", wallpapers: valid},
+		{name: "无效的收件人", from: "bing@example.com", to: []string{"alice@"}, wallpapers: valid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := NewEmailNotifier(SMTPConfig{}, tt.from, tt.to, nil)
+			if _, err := notifier.Message(tt.wallpapers, time.Now()); err == nil {
+				t.Error("应返回错误")
+			}
+		})
+	}
+}
+
+func TestParseSMTPSecurity(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    SMTPSecurity
+		wantErr bool
+	}{
+		{name: "starttls", want: SMTPStartTLS},
+		{name: " TLS ", want: SMTPTLS},
+		{name: "None", want: SMTPNone},
+		{name: "ssl", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSMTPSecurity(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSMTPSecurity(%q) error = %v", tt.name, err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseSMTPSecurity(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
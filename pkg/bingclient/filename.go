@@ -0,0 +1,210 @@
+package bingclient
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 模板中的占位符，如 {yyyy}
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// 图片 URL 中的信息，如 /th?id=OHR.BledLake_ZH-CN1234567890_1920x1080.jpg
+var (
+	imageNamePattern   = regexp.MustCompile(`OHR\.([A-Za-z0-9]+)_`)
+	imageMarketPattern = regexp.MustCompile(`_([A-Za-z]{2}-[A-Za-z]{2})\d+_`)
+	imageResPattern    = regexp.MustCompile(`_(\d+x\d+|UHD)\.([A-Za-z]+)`)
+)
+
+// templatePlaceholders 列出模板支持的全部占位符及说明
+var templatePlaceholders = map[string]string{
+	"yyyy":     "四位年份",
+	"mm":       "两位月份",
+	"dd":       "两位日期",
+	"date":     "日期 (YYYYMMDD)",
+	"market":   "市场代码 (如 zh-CN)",
+	"title":    "壁纸标题",
+	"location": "拍摄地点 (解析自版权信息)",
+	"credit":   "摄影师 (解析自版权信息)",
+	"name":     "图片名称 (如 BledLake)",
+	"res":      "分辨率 (如 UHD, 1920x1080)",
+	"ext":      "文件扩展名 (如 jpg)",
+	"hsh":      "图片哈希值",
+}
+
+// TemplatePlaceholders 返回模板支持的占位符及其说明
+func TemplatePlaceholders() map[string]string {
+	placeholders := make(map[string]string, len(templatePlaceholders))
+	for name, desc := range templatePlaceholders {
+		placeholders[name] = desc
+	}
+	return placeholders
+}
+
+// TemplateFilenameGenerator 是基于模板的文件名生成器
+// 模板使用 {yyyy}/{mm}/{date}_{market}_{title}_{res}.{ext} 形式的占位符，
+// 其中的 / 会生成子目录
+type TemplateFilenameGenerator struct {
+	ImageTemplate string // 图片路径模板
+	JsonTemplate  string // JSON 路径模板，为空时沿用图片路径并将扩展名替换为 .json
+	Market        string // 市场代码，为空时从图片 URL 中解析
+	Resolution    string // 分辨率，为空时从图片 URL 中解析
+	Logger        Logger // 日志记录器
+
+	// Sanitizer 用于清理占位符的取值，为 nil 时使用默认配置
+	Sanitizer *FilenameSanitizer
+}
+
+// NewTemplateFilenameGenerator 创建一个新的模板文件名生成器
+func NewTemplateFilenameGenerator(imageTemplate string, logger Logger) (*TemplateFilenameGenerator, error) {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	if err := ValidateNameTemplate(imageTemplate); err != nil {
+		return nil, err
+	}
+
+	return &TemplateFilenameGenerator{
+		ImageTemplate: imageTemplate,
+		Logger:        logger,
+		Sanitizer:     NewFilenameSanitizer(),
+	}, nil
+}
+
+// ValidateNameTemplate 检查模板是否有效
+func ValidateNameTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("文件名模板不能为空")
+	}
+	if filepath.IsAbs(template) || strings.HasPrefix(template, "/") {
+		return fmt.Errorf("文件名模板必须是相对路径: %s", template)
+	}
+	for _, segment := range strings.FieldsFunc(template, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return fmt.Errorf("文件名模板不能包含 .. 路径: %s", template)
+		}
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := templatePlaceholders[match[1]]; !ok {
+			return fmt.Errorf("文件名模板包含未知的占位符: {%s}", match[1])
+		}
+	}
+	return nil
+}
+
+// GenerateImageFilename 根据模板生成图片文件路径
+func (g *TemplateFilenameGenerator) GenerateImageFilename(imageData *ImageData, basePath string) string {
+	filename := g.render(g.ImageTemplate, imageData)
+
+	g.Logger.Debug("生成图片文件名: %s", filename)
+	return g.join(basePath, filename)
+}
+
+// GenerateJsonFilename 根据模板生成 JSON 文件路径
+func (g *TemplateFilenameGenerator) GenerateJsonFilename(imageData *ImageData, basePath string) string {
+	var filename string
+	if g.JsonTemplate != "" {
+		filename = g.render(g.JsonTemplate, imageData)
+	} else {
+		filename = g.render(g.ImageTemplate, imageData)
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".json"
+	}
+
+	g.Logger.Debug("生成 JSON 文件名: %s", filename)
+	return g.join(basePath, filename)
+}
+
+// join 拼接输出路径，模板生成的路径超出输出目录时退回到只使用文件名
+func (g *TemplateFilenameGenerator) join(basePath, filename string) string {
+	joined, err := SafeJoin(basePath, filename)
+	if err != nil {
+		g.Logger.Warning("模板生成的路径无效，仅使用文件名: %v", err)
+		return filepath.Join(basePath, SanitizeFilename(filepath.Base(filename)))
+	}
+	return joined
+}
+
+// render 替换模板中的占位符
+func (g *TemplateFilenameGenerator) render(template string, imageData *ImageData) string {
+	values := g.values(imageData)
+	rendered := placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	})
+	return filepath.FromSlash(rendered)
+}
+
+// values 计算占位符的取值
+func (g *TemplateFilenameGenerator) values(imageData *ImageData) map[string]string {
+	date := imageData.Startdate
+	var year, month, day string
+	if len(date) == 8 {
+		year, month, day = date[0:4], date[4:6], date[6:8]
+	}
+
+	market := g.Market
+	if market == "" {
+		market = ParseImageMarket(imageData)
+	}
+
+	resolution := g.Resolution
+	if resolution == "" {
+		resolution, _ = parseImageResolution(imageData)
+	}
+
+	_, ext := parseImageResolution(imageData)
+	if ext == "" {
+		ext = "jpg"
+	}
+
+	sanitizer := g.Sanitizer
+	if sanitizer == nil {
+		sanitizer = NewFilenameSanitizer()
+	}
+
+	copyright := ParseCopyright(imageData.Copyright)
+
+	// 每个取值都单独清理，避免标题等内容生成意外的子目录
+	return map[string]string{
+		"yyyy":     sanitizer.Sanitize(year),
+		"mm":       sanitizer.Sanitize(month),
+		"dd":       sanitizer.Sanitize(day),
+		"date":     sanitizer.Sanitize(date),
+		"market":   sanitizer.Sanitize(market),
+		"title":    sanitizer.Sanitize(wallpaperDescription(imageData)),
+		"location": sanitizer.Sanitize(copyright.Location),
+		"credit":   sanitizer.Sanitize(copyright.Credit),
+		"name":     sanitizer.Sanitize(ParseImageName(imageData)),
+		"res":      sanitizer.Sanitize(resolution),
+		"ext":      sanitizer.Sanitize(ext),
+		"hsh":      sanitizer.Sanitize(imageData.Hsh),
+	}
+}
+
+// ParseImageName 从图片 URL 中解析图片名称，如 BledLake
+func ParseImageName(imageData *ImageData) string {
+	if match := imageNamePattern.FindStringSubmatch(imageData.URL); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// ParseImageMarket 从图片 URL 中解析市场代码，如 zh-CN
+func ParseImageMarket(imageData *ImageData) string {
+	match := imageMarketPattern.FindStringSubmatch(imageData.URL)
+	if match == nil {
+		return ""
+	}
+	parts := strings.SplitN(match[1], "-", 2)
+	return strings.ToLower(parts[0]) + "-" + strings.ToUpper(parts[1])
+}
+
+// parseImageResolution 从图片 URL 中解析分辨率和扩展名
+func parseImageResolution(imageData *ImageData) (string, string) {
+	if match := imageResPattern.FindStringSubmatch(imageData.URL); match != nil {
+		return match[1], strings.ToLower(match[2])
+	}
+	return "", ""
+}
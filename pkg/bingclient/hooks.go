@@ -0,0 +1,163 @@
+package bingclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout 是钩子命令的默认超时时间
+const DefaultHookTimeout = 30 * time.Second
+
+// DownloadHook 是新壁纸保存后调用的钩子
+// 只对成功保存的壁纸调用，图片未变化（Unchanged）时不会调用
+type DownloadHook interface {
+	// OnNewWallpaper 处理新保存的壁纸，返回的错误只记录在结果中，不影响下载结果
+	OnNewWallpaper(result *DownloadResult) error
+}
+
+// HookFunc 将普通函数适配为 DownloadHook
+type HookFunc func(result *DownloadResult) error
+
+// OnNewWallpaper 调用函数本身
+func (f HookFunc) OnNewWallpaper(result *DownloadResult) error {
+	return f(result)
+}
+
+// WallpaperEvent 是新壁纸事件的数据，以 JSON 形式传递给钩子命令
+type WallpaperEvent struct {
+	Event        string         `json:"event"`                  // 事件名称，固定为 new-wallpaper
+	ImagePath    string         `json:"imagePath"`              // 图片保存路径
+	MetadataPath string         `json:"metadataPath,omitempty"` // 元数据文件保存路径
+	Variants     []*EventFile   `json:"variants,omitempty"`     // 派生图片
+	Metadata     *ImageMetadata `json:"metadata"`               // 规范化元数据
+}
+
+// EventFile 是事件中的一张派生图片
+type EventFile struct {
+	Kind   string `json:"kind"`   // 类型，如 thumbnail、crop
+	Name   string `json:"name"`   // 名称，如 320w
+	Path   string `json:"path"`   // 保存路径
+	Width  int    `json:"width"`  // 宽度
+	Height int    `json:"height"` // 高度
+}
+
+// NewWallpaperEvent 根据下载结果生成事件数据
+func NewWallpaperEvent(result *DownloadResult) *WallpaperEvent {
+	meta := result.Metadata
+	if meta == nil {
+		meta = NewImageMetadata(&result.ImageData, nil)
+	}
+	event := &WallpaperEvent{
+		Event:        "new-wallpaper",
+		ImagePath:    result.ImagePath,
+		MetadataPath: result.JsonPath,
+		Metadata:     meta,
+	}
+	for _, variant := range result.Variants {
+		event.Variants = append(event.Variants, &EventFile{
+			Kind:   variant.Kind.String(),
+			Name:   variant.Name,
+			Path:   variant.Path,
+			Width:  variant.Width,
+			Height: variant.Height,
+		})
+	}
+	return event
+}
+
+// Env 返回传递给钩子命令的环境变量，形如 BING_TITLE=...
+func (e *WallpaperEvent) Env() []string {
+	meta := e.Metadata
+	values := [][2]string{
+		{"BING_EVENT", e.Event},
+		{"BING_IMAGE_PATH", e.ImagePath},
+		{"BING_METADATA_PATH", e.MetadataPath},
+		{"BING_TITLE", meta.Title},
+		{"BING_DATE", meta.Date},
+		{"BING_STARTDATE", meta.StartDate},
+		{"BING_MARKET", meta.Market},
+		{"BING_COPYRIGHT", meta.Copyright},
+		{"BING_COPYRIGHT_LINK", meta.CopyrightLink},
+		{"BING_DESCRIPTION", meta.Description},
+		{"BING_LOCATION", meta.Location},
+		{"BING_CREDIT", meta.Credit},
+		{"BING_URL", meta.URL},
+		{"BING_SHA256", meta.SHA256},
+	}
+	env := make([]string, 0, len(values))
+	for _, kv := range values {
+		env = append(env, kv[0]+"="+kv[1])
+	}
+	return env
+}
+
+// CommandHook 通过 shell 执行外部命令的钩子
+// 事件数据通过 BING_* 环境变量和标准输入中的 JSON 传递，命令的输出记录到日志
+type CommandHook struct {
+	Command string        // 命令，在 Unix 上由 sh -c 执行，在 Windows 上由 cmd /C 执行
+	Timeout time.Duration // 超时时间，为 0 时使用 DefaultHookTimeout
+	Logger  Logger        // 日志记录器
+}
+
+// NewCommandHook 创建一个执行外部命令的钩子
+func NewCommandHook(command string, timeout time.Duration, logger Logger) *CommandHook {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+	return &CommandHook{Command: command, Timeout: timeout, Logger: logger}
+}
+
+// OnNewWallpaper 执行命令，命令以非零状态退出或超时时返回错误
+func (h *CommandHook) OnNewWallpaper(result *DownloadResult) error {
+	event := NewWallpaperEvent(result)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("编码事件数据失败: %v", err)
+	}
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, h.Command)
+	cmd.Env = append(os.Environ(), event.Env()...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// 命令派生的子进程可能继续持有输出管道，超时后最多再等待一秒
+	cmd.WaitDelay = time.Second
+
+	h.Logger.Info("执行钩子: %s", h.Command)
+	err = cmd.Run()
+	for _, line := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+		if line != "" {
+			h.Logger.Info("[钩子] %s", line)
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("钩子 %q 执行超时 (%v)", h.Command, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("钩子 %q 执行失败: %v", h.Command, err)
+	}
+	return nil
+}
+
+// shellCommand 返回通过系统 shell 执行命令的 Cmd
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,228 @@
+package bingclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger 记录日志内容，用于检查钩子命令的输出
+type recordingLogger struct {
+	NullLogger
+	mu    sync.Mutex
+	lines []string // 形如 "INFO: ..." 的日志
+}
+
+func (l *recordingLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+": "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debug(format string, args ...interface{}) {
+	l.record("DEBUG", format, args...)
+}
+
+func (l *recordingLogger) Info(format string, args ...interface{}) {
+	l.record("INFO", format, args...)
+}
+
+func (l *recordingLogger) Warning(format string, args ...interface{}) {
+	l.record("WARNING", format, args...)
+}
+
+func (l *recordingLogger) Error(format string, args ...interface{}) {
+	l.record("ERROR", format, args...)
+}
+
+// has 返回是否记录过指定的日志
+func (l *recordingLogger) has(line string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, got := range l.lines {
+		if got == line {
+			return true
+		}
+	}
+	return false
+}
+
+// skipWithoutShell 在没有 sh 的平台上跳过测试
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("钩子测试使用 sh 脚本")
+	}
+}
+
+// testHookResult 返回测试用的下载结果
+func testHookResult() *DownloadResult {
+	return &DownloadResult{
+		ImagePath: "/walls/20261001_zh-CN.jpg",
+		JsonPath:  "/walls/20261001_zh-CN.json",
+		Metadata: &ImageMetadata{
+			Date:      "2026-10-01",
+			StartDate: "20261001",
+			Market:    "zh-CN",
+			Title:     "布莱德湖 \"Bled\" $HOME",
+			Copyright: "布莱德湖，斯洛文尼亚 (© Jane Doe/Getty Images)",
+			URL:       "https://www.bing.com/th?id=OHR.Bled_UHD.jpg",
+			SHA256:    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+}
+
+func TestCommandHookEnvAndStdin(t *testing.T) {
+	skipWithoutShell(t)
+	dir := t.TempDir()
+	t.Setenv("HOOK_OUT", dir)
+
+	logger := &recordingLogger{}
+	hook := NewCommandHook(`env | grep '^BING_' | LC_ALL=C sort > "$HOOK_OUT/env"
+cat > "$HOOK_OUT/stdin"
+echo "saved $BING_IMAGE_PATH"
+echo "warning on stderr" >&2`, 5*time.Second, logger)
+	if err := hook.OnNewWallpaper(testHookResult()); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, "env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"BING_COPYRIGHT=布莱德湖，斯洛文尼亚 (© Jane Doe/Getty Images)",
+		"BING_COPYRIGHT_LINK=",
+		"BING_CREDIT=",
+		"BING_DATE=2026-10-01",
+		"BING_DESCRIPTION=",
+		"BING_EVENT=new-wallpaper",
+		"BING_IMAGE_PATH=/walls/20261001_zh-CN.jpg",
+		"BING_LOCATION=",
+		"BING_MARKET=zh-CN",
+		"BING_METADATA_PATH=/walls/20261001_zh-CN.json",
+		"BING_SHA256=e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"BING_STARTDATE=20261001",
+		"BING_TITLE=布莱德湖 \"Bled\" $HOME",
+		"BING_URL=https://www.bing.com/th?id=OHR.Bled_UHD.jpg",
+	}
+	if got := strings.Split(strings.TrimRight(string(env), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("环境变量\n got: %q\nwant: %q", got, want)
+	}
+
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event WallpaperEvent
+	if err := json.Unmarshal(stdin, &event); err != nil {
+		t.Fatalf("标准输入不是有效的 JSON: %v\n%s", err, stdin)
+	}
+	if event.Event != "new-wallpaper" || event.ImagePath != "/walls/20261001_zh-CN.jpg" || event.MetadataPath != "/walls/20261001_zh-CN.json" {
+		t.Errorf("事件数据 = %+v", event)
+	}
+	if event.Metadata == nil || event.Metadata.Title != testHookResult().Metadata.Title {
+		t.Errorf("事件元数据 = %+v", event.Metadata)
+	}
+
+	for _, line := range []string{"INFO: [钩子] saved /walls/20261001_zh-CN.jpg", "INFO: [钩子] warning on stderr"} {
+		if !logger.has(line) {
+			t.Errorf("日志中缺少 %q: %q", line, logger.lines)
+		}
+	}
+}
+
+func TestCommandHookErrors(t *testing.T) {
+	skipWithoutShell(t)
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		wantErr string   // 期望错误中包含的内容
+		wantLog []string // 期望记录的日志
+	}{
+		{
+			name:    "非零状态退出",
+			command: `echo "boom" >&2; exit 3`,
+			timeout: 5 * time.Second,
+			wantErr: "执行失败",
+			wantLog: []string{"INFO: [钩子] boom"},
+		},
+		{
+			name:    "超时",
+			command: `echo "started"; sleep 10`,
+			timeout: 200 * time.Millisecond,
+			wantErr: "执行超时",
+			wantLog: []string{"INFO: [钩子] started"},
+		},
+		{
+			// 后台子进程持有输出管道，超时后不应等待它结束
+			name:    "超时后子进程仍持有输出",
+			command: `sleep 10 & sleep 10`,
+			timeout: 200 * time.Millisecond,
+			wantErr: "执行超时",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			start := time.Now()
+			err := NewCommandHook(tt.command, tt.timeout, logger).OnNewWallpaper(testHookResult())
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("钩子执行了 %v，超时没有生效", elapsed)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("应返回包含 %q 的错误，实际为 %v", tt.wantErr, err)
+			}
+			for _, line := range tt.wantLog {
+				if !logger.has(line) {
+					t.Errorf("日志中缺少 %q: %q", line, logger.lines)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloaderRunHooks(t *testing.T) {
+	hookErr := errors.New("hook failed")
+	tests := []struct {
+		name      string
+		unchanged bool
+		skipped   bool
+		wantCalls int
+	}{
+		{name: "新壁纸", wantCalls: 2},
+		{name: "图片未变化", unchanged: true},
+		{name: "图片已存在", unchanged: true, skipped: true},
+		{name: "只标记为跳过", skipped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			d := &Downloader{Logger: &NullLogger{}}
+			d.AddHook(HookFunc(func(*DownloadResult) error { calls++; return hookErr }))
+			d.AddHook(HookFunc(func(*DownloadResult) error { calls++; return nil }))
+
+			result := testHookResult()
+			result.Unchanged, result.Skipped = tt.unchanged, tt.skipped
+			d.runHooks(result)
+
+			if calls != tt.wantCalls {
+				t.Errorf("钩子被调用 %d 次, want %d", calls, tt.wantCalls)
+			}
+			if wantErrs := min(tt.wantCalls, 1); len(result.HookErrs) != wantErrs {
+				t.Errorf("HookErrs = %v", result.HookErrs)
+			}
+		})
+	}
+}
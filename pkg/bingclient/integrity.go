@@ -0,0 +1,78 @@
+package bingclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image/jpeg"
+	"mime"
+	"os"
+	"strings"
+)
+
+// 默认的最小图片尺寸，Bing 壁纸最小为 1920x1080，明显更小的图片通常是占位图或错误页
+const (
+	DefaultMinImageWidth  = 1280
+	DefaultMinImageHeight = 720
+)
+
+// checkImageContentType 检查响应的 Content-Type 是否为 JPEG 图片
+// 服务器未返回 Content-Type 时不做检查，交由后续的解码校验
+func checkImageContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("无效的 Content-Type: %s", contentType)
+	}
+	switch strings.ToLower(mediaType) {
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+		return nil
+	default:
+		return fmt.Errorf("响应不是 JPEG 图片 (Content-Type: %s)", contentType)
+	}
+}
+
+// ValidateJPEG 完整解码 JPEG 数据并检查最小尺寸，minWidth 或 minHeight 为 0 时不检查该项
+// 截断或损坏的数据、HTML 错误页等都会在解码时被发现
+func ValidateJPEG(data []byte, minWidth, minHeight int) error {
+	if len(data) == 0 {
+		return fmt.Errorf("图片数据为空")
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("图片解码失败: %v", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() < minWidth || bounds.Dy() < minHeight {
+		return fmt.Errorf("图片尺寸过小: %dx%d (最小 %dx%d)", bounds.Dx(), bounds.Dy(), minWidth, minHeight)
+	}
+	return nil
+}
+
+// VerifyWallpaper 校验本地壁纸：完整解码并检查最小尺寸，有元数据时还会比对大小和 SHA-256
+func VerifyWallpaper(w *LocalWallpaper, minWidth, minHeight int) error {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return fmt.Errorf("读取图片失败: %v", err)
+	}
+
+	if w.Metadata != nil {
+		if w.Metadata.Size > 0 && int64(len(data)) != w.Metadata.Size {
+			return fmt.Errorf("文件大小不一致: %d 字节 (元数据记录 %d 字节)", len(data), w.Metadata.Size)
+		}
+		if w.Metadata.SHA256 != "" {
+			sum := sha256.Sum256(data)
+			if !strings.EqualFold(hex.EncodeToString(sum[:]), w.Metadata.SHA256) {
+				return fmt.Errorf("SHA-256 校验和不一致")
+			}
+		}
+	}
+
+	return ValidateJPEG(data, minWidth, minHeight)
+}
@@ -0,0 +1,225 @@
+package bingclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// JPEG 标记
+const (
+	jpegMarkerSOI   = 0xD8
+	jpegMarkerSOS   = 0xDA
+	jpegMarkerEOI   = 0xD9
+	jpegMarkerAPP0  = 0xE0
+	jpegMarkerAPP1  = 0xE1
+	jpegMarkerAPP13 = 0xED
+)
+
+// 单个 JPEG 段的最大数据长度（长度字段本身占 2 字节）
+const maxJPEGSegmentSize = 0xFFFF - 2
+
+var (
+	xmpSignature       = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	exifSignature      = []byte("Exif\x00\x00")
+	photoshopSignature = []byte("Photoshop 3.0\x00")
+)
+
+// EmbeddedMetadata 是写入 JPEG 文件的描述信息
+type EmbeddedMetadata struct {
+	Title       string // 标题 (dc:title, IPTC Object Name)
+	Description string // 描述 (dc:description, IPTC Caption)
+	Rights      string // 版权信息 (dc:rights, IPTC Copyright Notice)
+	Source      string // 来源链接 (dc:source)
+	Credit      string // 图片作者 (photoshop:Credit, IPTC Credit)
+	Date        string // 日期 (YYYYMMDD)
+}
+
+// NewEmbeddedMetadata 根据壁纸数据生成要写入 JPEG 的描述信息
+func NewEmbeddedMetadata(imageData *ImageData) *EmbeddedMetadata {
+	return &EmbeddedMetadata{
+		Title:       imageData.Title,
+		Description: imageData.Copyright,
+		Rights:      imageData.Copyright,
+		Source:      imageData.Copyrightlink,
+		Credit:      ParseCopyright(imageData.Copyright).CreditLine(),
+		Date:        imageData.Startdate,
+	}
+}
+
+// EmbedJPEGMetadata 将 XMP 和 IPTC 描述信息写入 JPEG 数据
+// 已有的 XMP 和 Photoshop (IPTC) 段会被替换，其他段保持不变
+func EmbedJPEGMetadata(data []byte, meta *EmbeddedMetadata) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegMarkerSOI {
+		return nil, fmt.Errorf("不是有效的 JPEG 数据")
+	}
+
+	xmpSegment, err := jpegSegment(jpegMarkerAPP1, append(append([]byte{}, xmpSignature...), buildXMPPacket(meta)...))
+	if err != nil {
+		return nil, fmt.Errorf("生成 XMP 数据失败: %v", err)
+	}
+	iptcSegment, err := jpegSegment(jpegMarkerAPP13, buildPhotoshopIPTC(meta))
+	if err != nil {
+		return nil, fmt.Errorf("生成 IPTC 数据失败: %v", err)
+	}
+
+	var leading, trailing bytes.Buffer
+	pos := 2
+	inLeading := true
+	for {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, fmt.Errorf("JPEG 段结构无效 (偏移 %d)", pos)
+		}
+		marker := data[pos+1]
+		// 遇到图像数据后停止解析，剩余部分原样保留
+		if marker == jpegMarkerSOS || marker == jpegMarkerEOI {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("JPEG 段长度无效 (偏移 %d)", pos)
+		}
+		segment := data[pos:end]
+		payload := data[pos+4 : end]
+		pos = end
+
+		switch {
+		case marker == jpegMarkerAPP1 && bytes.HasPrefix(payload, xmpSignature):
+			continue
+		case marker == jpegMarkerAPP13 && bytes.HasPrefix(payload, photoshopSignature):
+			continue
+		case inLeading && (marker == jpegMarkerAPP0 || (marker == jpegMarkerAPP1 && bytes.HasPrefix(payload, exifSignature))):
+			// JFIF 和 Exif 段需要位于文件开头
+			leading.Write(segment)
+		default:
+			inLeading = false
+			trailing.Write(segment)
+		}
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(data) + len(xmpSegment) + len(iptcSegment))
+	out.Write([]byte{0xFF, jpegMarkerSOI})
+	out.Write(leading.Bytes())
+	out.Write(xmpSegment)
+	out.Write(iptcSegment)
+	out.Write(trailing.Bytes())
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
+
+// jpegSegment 生成带标记和长度的 JPEG 段
+func jpegSegment(marker byte, payload []byte) ([]byte, error) {
+	if len(payload) > maxJPEGSegmentSize {
+		return nil, fmt.Errorf("数据过长 (%d 字节)", len(payload))
+	}
+	segment := make([]byte, 4, 4+len(payload))
+	segment[0] = 0xFF
+	segment[1] = marker
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...), nil
+}
+
+// xmlEscape 转义 XML 文本
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// buildXMPPacket 生成 XMP 数据包
+func buildXMPPacket(meta *EmbeddedMetadata) []byte {
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(xmpDocument(meta))
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// xmpDocument 生成 x:xmpmeta 文档
+func xmpDocument(meta *EmbeddedMetadata) string {
+	var b strings.Builder
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:photoshop=\"http://ns.adobe.com/photoshop/1.0/\"\n")
+	b.WriteString("    xmlns:xmpRights=\"http://ns.adobe.com/xap/1.0/rights/\">\n")
+
+	writeAlt := func(name, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "   <%s><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></%s>\n", name, xmlEscape(value), name)
+	}
+	writeSimple := func(name, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "   <%s>%s</%s>\n", name, xmlEscape(value), name)
+	}
+
+	writeAlt("dc:title", meta.Title)
+	writeAlt("dc:description", meta.Description)
+	writeAlt("dc:rights", meta.Rights)
+	writeSimple("dc:source", meta.Source)
+	writeSimple("photoshop:Credit", meta.Credit)
+	if len(meta.Date) == 8 {
+		writeSimple("photoshop:DateCreated", fmt.Sprintf("%s-%s-%s", meta.Date[0:4], meta.Date[4:6], meta.Date[6:8]))
+	}
+	if meta.Rights != "" {
+		writeSimple("xmpRights:Marked", "True")
+	}
+	writeSimple("xmpRights:WebStatement", meta.Source)
+
+	b.WriteString("  </rdf:Description>\n")
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	return b.String()
+}
+
+// buildPhotoshopIPTC 生成包含 IPTC-IIM 数据的 Photoshop APP13 段内容
+func buildPhotoshopIPTC(meta *EmbeddedMetadata) []byte {
+	var iptc bytes.Buffer
+	writeDataset := func(record, dataset byte, value []byte) {
+		iptc.Write([]byte{0x1C, record, dataset})
+		binary.Write(&iptc, binary.BigEndian, uint16(len(value)))
+		iptc.Write(value)
+	}
+	writeText := func(dataset byte, value string, maxBytes int) {
+		if value == "" {
+			return
+		}
+		writeDataset(2, dataset, []byte(TruncateUTF8(value, maxBytes)))
+	}
+
+	// 1:90 字符集声明为 UTF-8
+	writeDataset(1, 90, []byte{0x1B, 0x25, 0x47})
+	// 2:00 记录版本
+	writeDataset(2, 0, []byte{0x00, 0x04})
+	writeText(5, meta.Title, 64)
+	if len(meta.Date) == 8 {
+		writeText(55, meta.Date, 8)
+	}
+	writeText(110, meta.Credit, 32)
+	writeText(115, "Microsoft Bing", 32)
+	writeText(116, meta.Rights, 128)
+	writeText(120, meta.Description, 2000)
+
+	// Photoshop 图像资源块: 8BIM + 资源 ID + 空名称 + 数据长度 + 数据
+	var out bytes.Buffer
+	out.Write(photoshopSignature)
+	out.WriteString("8BIM")
+	binary.Write(&out, binary.BigEndian, uint16(0x0404))
+	out.Write([]byte{0x00, 0x00})
+	binary.Write(&out, binary.BigEndian, uint32(iptc.Len()))
+	out.Write(iptc.Bytes())
+	if iptc.Len()%2 == 1 {
+		out.WriteByte(0x00)
+	}
+	return out.Bytes()
+}
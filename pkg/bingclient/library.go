@@ -0,0 +1,239 @@
+package bingclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LocalWallpaper 是本地壁纸目录中已保存的一张壁纸
+type LocalWallpaper struct {
+	Path         string         // 图片路径
+	MetadataPath string         // 元数据文件路径，没有时为空
+	Metadata     *ImageMetadata // 元数据，没有或无法解析（如 XMP）时为 nil
+	Size         int64          // 图片字节数
+}
+
+// Date 返回壁纸日期 (YYYYMMDD)，优先使用元数据，否则从文件名中提取
+func (w *LocalWallpaper) Date() string {
+	if w.Metadata != nil && len(w.Metadata.StartDate) == 8 {
+		return w.Metadata.StartDate
+	}
+	date, _ := DateFromFilename(w.Path)
+	return date
+}
+
+// RelatedFiles 返回与壁纸一同保存的元数据文件、缩略图和派生图片中实际存在的文件
+func (w *LocalWallpaper) RelatedFiles() []string {
+	var files []string
+	if w.MetadataPath != "" {
+		files = append(files, w.MetadataPath)
+	}
+
+	// 派生图片命名为 <原图名称>_<后缀>.jpg，见 variantPath
+	prefix := strings.TrimSuffix(filepath.Base(w.Path), filepath.Ext(w.Path)) + "_"
+	for _, subdir := range []string{ThumbnailDir, VariantDir} {
+		dir := filepath.Join(filepath.Dir(w.Path), subdir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) && strings.EqualFold(filepath.Ext(entry.Name()), ".jpg") {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// Remove 删除壁纸及其元数据文件、缩略图和派生图片，并同步更新所在目录的校验和清单
+// 返回已删除的文件，删除失败时立即返回错误
+func (w *LocalWallpaper) Remove(logger Logger) ([]string, error) {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	var removed []string
+	for _, path := range append([]string{w.Path}, w.RelatedFiles()...) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Error("删除 %s 失败: %v", path, err)
+			return removed, fmt.Errorf("删除 %s 失败: %v", path, err)
+		}
+		removed = append(removed, path)
+		logger.Debug("已删除: %s", path)
+		if err := UpdateManifest(path); err != nil {
+			logger.Warning("更新校验和清单失败: %v", err)
+		}
+	}
+	return removed, nil
+}
+
+// DiskUsage 返回壁纸及其元数据文件、缩略图和派生图片占用的字节数
+func (w *LocalWallpaper) DiskUsage() int64 {
+	total := w.Size
+	for _, path := range w.RelatedFiles() {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// 文件名中的日期 (YYYYMMDD)
+var filenameDatePattern = regexp.MustCompile(`(?:^|[^0-9])((?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01]))(?:[^0-9]|$)`)
+
+// DateFromFilename 从文件名中提取日期 (YYYYMMDD)
+func DateFromFilename(name string) (string, bool) {
+	match := filenameDatePattern.FindStringSubmatch(filepath.Base(name))
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// 文件名中的市场代码，如 zh-CN、en-US
+var filenameMarketPattern = regexp.MustCompile(`(?:^|[^A-Za-z])([a-z]{2}-[A-Z]{2})(?:[^A-Za-z]|$)`)
+
+// MarketFromFilename 从文件名中提取已知的市场代码，如 "20261001_en-US.jpg" 中的 en-US
+func MarketFromFilename(name string) (string, bool) {
+	for _, match := range filenameMarketPattern.FindAllStringSubmatch(filepath.Base(name), -1) {
+		if _, ok := marketZones[match[1]]; ok {
+			return match[1], true
+		}
+	}
+	return "", false
+}
+
+// ScanWallpapers 扫描目录中的壁纸及其元数据文件，结果按日期和路径排序
+// 别名文件、缩略图和派生图片目录会被跳过；元数据通过其中记录的图片路径与图片关联
+func ScanWallpapers(dir string) ([]*LocalWallpaper, error) {
+	wallpapers := make(map[string]*LocalWallpaper)
+	var metadataFiles []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (entry.Name() == ThumbnailDir || entry.Name() == VariantDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsAliasFile(entry.Name()) {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".jpg", ".jpeg":
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			wallpapers[path] = &LocalWallpaper{Path: path, Size: info.Size()}
+		case ".json", ".yaml", ".yml", ".xmp":
+			metadataFiles = append(metadataFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描壁纸目录失败: %v", err)
+	}
+
+	for _, path := range metadataFiles {
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == ".xmp" {
+			// XMP 边车文件与图片同名
+			base := strings.TrimSuffix(path, filepath.Ext(path))
+			for _, imageExt := range []string{".jpg", ".jpeg"} {
+				if w, ok := wallpapers[base+imageExt]; ok && w.MetadataPath == "" {
+					w.MetadataPath = path
+				}
+			}
+			continue
+		}
+
+		meta, err := LoadImageMetadata(path)
+		if err != nil || meta.Image == "" {
+			// 原始 JSON 等其他文件
+			continue
+		}
+		imagePath := filepath.Join(filepath.Dir(path), filepath.FromSlash(meta.Image))
+		if w, ok := wallpapers[imagePath]; ok {
+			w.MetadataPath = path
+			w.Metadata = meta
+		}
+	}
+
+	result := make([]*LocalWallpaper, 0, len(wallpapers))
+	for _, w := range wallpapers {
+		result = append(result, w)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if di, dj := result[i].Date(), result[j].Date(); di != dj {
+			return di < dj
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// LoadImageMetadata 读取 JSON 或 YAML 格式的元数据文件，格式由扩展名决定
+func LoadImageMetadata(path string) (*ImageMetadata, error) {
+	format, err := metadataFormatForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取元数据文件失败: %v", err)
+	}
+	return UnmarshalImageMetadata(data, format)
+}
+
+// RefreshChecksum 根据图片文件当前的内容更新元数据中的大小和 SHA-256，并写回元数据文件
+// 没有可解析的元数据时不做任何操作
+func (w *LocalWallpaper) RefreshChecksum(storage Storage) error {
+	if w.Metadata == nil || w.MetadataPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return fmt.Errorf("读取图片失败: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	w.Metadata.Size = int64(len(data))
+	w.Metadata.SHA256 = hex.EncodeToString(sum[:])
+	w.Size = w.Metadata.Size
+
+	format, err := metadataFormatForPath(w.MetadataPath)
+	if err != nil {
+		return err
+	}
+	encoded, err := w.Metadata.Marshal(format)
+	if err != nil {
+		return fmt.Errorf("序列化元数据失败: %v", err)
+	}
+	return storage.Save(encoded, w.MetadataPath)
+}
+
+// metadataFormatForPath 根据扩展名返回可读取的元数据格式
+func metadataFormatForPath(path string) (MetadataFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return MetadataJSON, nil
+	case ".yaml", ".yml":
+		return MetadataYAML, nil
+	default:
+		return 0, fmt.Errorf("不支持的元数据文件: %s", path)
+	}
+}
@@ -0,0 +1,365 @@
+package bingclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ManifestName 是每个目录中校验和清单的文件名，格式与 sha256sum 的输出兼容
+const ManifestName = "SHA256SUMS"
+
+// ManifestLockName 是串行化清单更新的锁文件名，与清单位于同一目录
+const ManifestLockName = ".SHA256SUMS.lock"
+
+// Manifest 是单个目录的校验和清单，记录文件名到 SHA-256 的映射
+type Manifest struct {
+	Entries map[string]string // 文件名（不含目录）到十六进制 SHA-256 的映射
+}
+
+// NewManifest 创建一个空的清单
+func NewManifest() *Manifest {
+	return &Manifest{Entries: make(map[string]string)}
+}
+
+// ParseManifest 解析 sha256sum 格式的清单，每行为 "<校验和>  <文件名>"，二进制模式的 "*" 前缀会被忽略
+func ParseManifest(data []byte) (*Manifest, error) {
+	manifest := NewManifest()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(text, " ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("清单第 %d 行格式无效", line)
+		}
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, fmt.Errorf("清单第 %d 行校验和无效", line)
+		}
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+		if name == "" {
+			return nil, fmt.Errorf("清单第 %d 行缺少文件名", line)
+		}
+		manifest.Entries[name] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取清单失败: %v", err)
+	}
+	return manifest, nil
+}
+
+// Marshal 按文件名排序输出 sha256sum 格式的清单
+func (m *Manifest) Marshal() []byte {
+	names := make([]string, 0, len(m.Entries))
+	for name := range m.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", m.Entries[name], name)
+	}
+	return buf.Bytes()
+}
+
+// ManifestStorage 是记录校验和的存储包装器
+// 每次保存文件时，都会在文件所在目录的 SHA256SUMS 清单中更新该文件的校验和
+//
+// 后端为 FileStorage 时，数据文件和新清单都先完整写入临时文件，全部成功后才依次重命名为目标文件，
+// 写入失败不会修改任何文件；两次重命名不是一个原子操作，只有在两次重命名之间崩溃时，
+// 清单才会落后于数据文件，此时 VerifyManifests 会报告该文件，重新保存即可恢复。
+// 清单在目录中的 .SHA256SUMS.lock 锁文件上加锁后更新，Unix 系统上使用 flock，同一目录的多个进程不会互相覆盖；
+// 其他系统上只在进程内串行化。
+//
+// 其他后端先保存数据再通过后端写入清单，清单写入失败时 Save 返回错误，但数据文件已经保存
+type ManifestStorage struct {
+	Backend Storage // 实际保存数据的存储
+	Logger  Logger  // 日志记录器
+
+	mu sync.Mutex // 串行化进程内的清单更新
+}
+
+// NewManifestStorage 创建一个记录校验和的存储包装器
+func NewManifestStorage(backend Storage, logger Logger) *ManifestStorage {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	return &ManifestStorage{
+		Backend: backend,
+		Logger:  logger,
+	}
+}
+
+// Save 保存数据并更新清单
+func (ms *ManifestStorage) Save(data []byte, path string) error {
+	if fs, ok := ms.Backend.(*FileStorage); ok {
+		return ms.saveFile(fs, bytes.NewReader(data), path)
+	}
+	if err := ms.Backend.Save(data, path); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	return ms.record(path, hex.EncodeToString(sum[:]))
+}
+
+// SaveReader 从读取器保存数据，同时计算校验和并更新清单
+func (ms *ManifestStorage) SaveReader(reader io.Reader, path string) error {
+	if fs, ok := ms.Backend.(*FileStorage); ok {
+		return ms.saveFile(fs, reader, path)
+	}
+	hash := sha256.New()
+	if err := ms.Backend.SaveReader(io.TeeReader(reader, hash), path); err != nil {
+		return err
+	}
+	return ms.record(path, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// Exists 检查路径是否存在
+func (ms *ManifestStorage) Exists(path string) bool {
+	return ms.Backend.Exists(path)
+}
+
+// Load 从后端存储读取数据，后端不支持读取时返回错误
+func (ms *ManifestStorage) Load(path string) ([]byte, error) {
+	loadable, ok := ms.Backend.(LoadableStorage)
+	if !ok {
+		return nil, fmt.Errorf("后端存储不支持读取")
+	}
+	return loadable.Load(path)
+}
+
+// saveFile 在清单锁内将数据和更新后的清单都写入临时文件，全部成功后依次重命名为目标文件
+func (ms *ManifestStorage) saveFile(fs *FileStorage, reader io.Reader, path string) error {
+	if filepath.Base(path) == ManifestName {
+		return fs.SaveReader(reader, path)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, fs.MkdirPermission); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	unlock, err := lockManifestDir(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	hash := sha256.New()
+	dataTmp, err := fs.writeTemp(path, io.TeeReader(reader, hash))
+	if err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	manifest, err := ms.load(dir)
+	if err != nil {
+		os.Remove(dataTmp)
+		return err
+	}
+	manifest.Entries[filepath.Base(path)] = sum
+	manifestPath := filepath.Join(dir, ManifestName)
+	manifestTmp, err := fs.writeTemp(manifestPath, bytes.NewReader(manifest.Marshal()))
+	if err != nil {
+		os.Remove(dataTmp)
+		return fmt.Errorf("更新校验和清单失败: %v", err)
+	}
+
+	if err := fs.commitTemp(dataTmp, path); err != nil {
+		os.Remove(manifestTmp)
+		return err
+	}
+	if err := fs.commitTemp(manifestTmp, manifestPath); err != nil {
+		ms.Logger.Error("更新校验和清单失败: %v", err)
+		return fmt.Errorf("更新校验和清单失败: %v", err)
+	}
+
+	fs.Logger.Info("成功保存数据到: %s", path)
+	ms.Logger.Debug("已记录校验和: %s %s", sum, path)
+	return nil
+}
+
+// record 在文件所在目录的清单中记录校验和并写回清单
+func (ms *ManifestStorage) record(path, sum string) error {
+	if filepath.Base(path) == ManifestName {
+		return nil
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	dir := filepath.Dir(path)
+	manifest, err := ms.load(dir)
+	if err != nil {
+		return err
+	}
+
+	manifest.Entries[filepath.Base(path)] = sum
+	if err := ms.Backend.Save(manifest.Marshal(), filepath.Join(dir, ManifestName)); err != nil {
+		ms.Logger.Error("更新校验和清单失败: %v", err)
+		return fmt.Errorf("更新校验和清单失败: %v", err)
+	}
+
+	ms.Logger.Debug("已记录校验和: %s %s", sum, path)
+	return nil
+}
+
+// load 从后端存储读取目录的清单，不存在或后端不支持读取时返回空清单
+func (ms *ManifestStorage) load(dir string) (*Manifest, error) {
+	manifest := NewManifest()
+	manifestPath := filepath.Join(dir, ManifestName)
+	if loadable, ok := ms.Backend.(LoadableStorage); ok && ms.Backend.Exists(manifestPath) {
+		data, err := loadable.Load(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取校验和清单失败: %v", err)
+		}
+		if manifest, err = ParseManifest(data); err != nil {
+			return nil, fmt.Errorf("解析校验和清单 %s 失败: %v", manifestPath, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+// UpdateManifest 根据文件当前的状态更新所在目录的清单：文件存在时记录其校验和，不存在时删除对应条目
+// 用于在 ManifestStorage 之外修改文件（如删除、替换为硬链接）后保持清单一致，目录中没有清单时不做任何操作
+func UpdateManifest(path string) error {
+	if path == "" {
+		return nil
+	}
+	manifestPath := filepath.Join(filepath.Dir(path), ManifestName)
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := lockManifestDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取校验和清单失败: %v", err)
+	}
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return fmt.Errorf("解析校验和清单 %s 失败: %v", manifestPath, err)
+	}
+
+	name := filepath.Base(path)
+	sum, err := fileSHA256(path)
+	switch {
+	case err == nil:
+		manifest.Entries[name] = sum
+	case os.IsNotExist(err):
+		delete(manifest.Entries, name)
+	default:
+		return err
+	}
+	return NewFileStorage(nil).Save(manifest.Marshal(), manifestPath)
+}
+
+// ManifestProblem 是清单校验中发现的问题
+type ManifestProblem struct {
+	Path string // 文件路径
+	Err  error  // 问题描述
+}
+
+// VerifyManifests 校验目录及其子目录中所有 SHA256SUMS 清单记录的文件
+// 返回发现的问题和已校验的文件数，文件缺失或校验和不一致都会被记录为问题
+func VerifyManifests(root string, logger Logger) ([]ManifestProblem, int, error) {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	var manifestPaths []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && entry.Name() == ManifestName {
+			manifestPaths = append(manifestPaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("扫描目录失败: %v", err)
+	}
+	if len(manifestPaths) == 0 {
+		return nil, 0, fmt.Errorf("目录中没有 %s 清单: %s", ManifestName, root)
+	}
+
+	var problems []ManifestProblem
+	checked := 0
+	for _, manifestPath := range manifestPaths {
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			return nil, checked, fmt.Errorf("读取校验和清单失败: %v", err)
+		}
+		manifest, err := ParseManifest(data)
+		if err != nil {
+			problems = append(problems, ManifestProblem{Path: manifestPath, Err: err})
+			continue
+		}
+
+		dir := filepath.Dir(manifestPath)
+		names := make([]string, 0, len(manifest.Entries))
+		for name := range manifest.Entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			checked++
+			sum, err := fileSHA256(path)
+			if os.IsNotExist(err) {
+				problems = append(problems, ManifestProblem{Path: path, Err: fmt.Errorf("文件不存在")})
+				continue
+			}
+			if err != nil {
+				problems = append(problems, ManifestProblem{Path: path, Err: err})
+				continue
+			}
+			if sum != manifest.Entries[name] {
+				problems = append(problems, ManifestProblem{Path: path, Err: fmt.Errorf("SHA-256 校验和不一致")})
+				continue
+			}
+			logger.Debug("校验通过: %s", path)
+		}
+	}
+	return problems, checked, nil
+}
+
+// fileSHA256 计算文件的十六进制 SHA-256
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("读取文件失败: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package bingclient
+
+// lockManifestDir 在不支持 flock 的系统上不加跨进程锁，清单更新只在进程内串行化
+func lockManifestDir(dir string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package bingclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockManifestDir 在目录的清单锁文件上加排他锁，返回解锁函数
+// 使用 flock，锁随文件描述符释放，进程异常退出时不会残留
+func lockManifestDir(dir string) (func(), error) {
+	file, err := os.OpenFile(filepath.Join(dir, ManifestLockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开清单锁文件失败: %v", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("锁定校验和清单失败: %v", err)
+	}
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,282 @@
+package bingclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MetadataFormat 表示元数据文件格式
+type MetadataFormat int
+
+const (
+	// MetadataJSON JSON 格式
+	MetadataJSON MetadataFormat = iota
+	// MetadataYAML YAML 格式
+	MetadataYAML
+	// MetadataXMP XMP 格式，保存为与图片同名的 .xmp 文件
+	MetadataXMP
+)
+
+// String 返回格式名称
+func (f MetadataFormat) String() string {
+	switch f {
+	case MetadataJSON:
+		return "json"
+	case MetadataYAML:
+		return "yaml"
+	case MetadataXMP:
+		return "xmp"
+	default:
+		return "unknown"
+	}
+}
+
+// Ext 返回格式对应的文件扩展名
+func (f MetadataFormat) Ext() string {
+	return "." + f.String()
+}
+
+// ParseMetadataFormat 根据名称解析元数据格式
+func ParseMetadataFormat(name string) (MetadataFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "json":
+		return MetadataJSON, nil
+	case "yaml", "yml":
+		return MetadataYAML, nil
+	case "xmp":
+		return MetadataXMP, nil
+	default:
+		return 0, fmt.Errorf("不支持的元数据格式: %s", name)
+	}
+}
+
+// ImageMetadata 是单张壁纸的规范化元数据，由已获取的 ImageData 生成
+type ImageMetadata struct {
+	Date          string    `json:"date"`          // 日期 (YYYY-MM-DD)
+	StartDate     string    `json:"startdate"`     // 原始开始日期 (YYYYMMDD)
+	FullStartDate string    `json:"fullstartdate"` // 原始完整开始时间
+	EndDate       string    `json:"enddate"`       // 原始结束日期
+	Market        string    `json:"market"`        // 市场代码
+	Resolution    string    `json:"resolution"`    // 分辨率
+	Title         string    `json:"title"`         // 标题
+	Copyright     string    `json:"copyright"`     // 版权信息
+	CopyrightLink string    `json:"copyrightLink"` // 版权链接
+	Description   string    `json:"description"`   // 画面描述（解析自版权信息）
+	Location      string    `json:"location"`      // 拍摄地点（解析自版权信息）
+	Credit        string    `json:"credit"`        // 摄影师（解析自版权信息）
+	Agency        string    `json:"agency"`        // 图片机构（解析自版权信息）
+	URL           string    `json:"url"`           // 图片下载地址
+	URLBase       string    `json:"urlbase"`       // 基础 URL
+	Hsh           string    `json:"hsh"`           // Bing 提供的哈希值
+	Image         string    `json:"image"`         // 图片文件路径（相对元数据文件所在目录）
+	Size          int64     `json:"size"`          // 图片字节数
+	SHA256        string    `json:"sha256"`        // 图片 SHA-256 校验和
+	DownloadedAt  time.Time `json:"downloadedAt"`  // 下载时间
+
+	Dominant string   `json:"dominant,omitempty"` // 主色 (#rrggbb)
+	Accent   string   `json:"accent,omitempty"`   // 强调色 (#rrggbb)
+	Palette  []string `json:"palette,omitempty"`  // 调色板，按占比从高到低排列
+	DHash    string   `json:"dhash,omitempty"`    // 感知哈希 (dHash)，用于查找重复图片
+}
+
+// NewImageMetadata 根据壁纸数据和已下载的图片生成规范化元数据
+func NewImageMetadata(imageData *ImageData, imageBytes []byte) *ImageMetadata {
+	sum := sha256.Sum256(imageBytes)
+	copyright := ParseCopyright(imageData.Copyright)
+
+	meta := &ImageMetadata{
+		StartDate:     imageData.Startdate,
+		FullStartDate: imageData.Fullstartdate,
+		EndDate:       imageData.Enddate,
+		Market:        ParseImageMarket(imageData),
+		Title:         imageData.Title,
+		Copyright:     imageData.Copyright,
+		CopyrightLink: imageData.Copyrightlink,
+		Description:   copyright.Description,
+		Location:      copyright.Location,
+		Credit:        copyright.Credit,
+		Agency:        copyright.Agency,
+		URLBase:       imageData.Urlbase,
+		Hsh:           imageData.Hsh,
+		Size:          int64(len(imageBytes)),
+		SHA256:        hex.EncodeToString(sum[:]),
+		DownloadedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	if len(imageData.Startdate) == 8 {
+		meta.Date = fmt.Sprintf("%s-%s-%s", imageData.Startdate[0:4], imageData.Startdate[4:6], imageData.Startdate[6:8])
+	}
+	meta.Resolution, _ = parseImageResolution(imageData)
+	return meta
+}
+
+// Marshal 按指定格式序列化元数据
+func (m *ImageMetadata) Marshal(format MetadataFormat) ([]byte, error) {
+	switch format {
+	case MetadataJSON:
+		return json.MarshalIndent(m, "", "  ")
+	case MetadataYAML:
+		return marshalFlatYAML(m)
+	case MetadataXMP:
+		embedded := &EmbeddedMetadata{
+			Title:       m.Title,
+			Description: m.Copyright,
+			Rights:      m.Copyright,
+			Source:      m.CopyrightLink,
+			Credit:      m.creditLine(),
+			Date:        m.StartDate,
+		}
+		return []byte(xmpDocument(embedded)), nil
+	default:
+		return nil, fmt.Errorf("不支持的元数据格式: %s", format)
+	}
+}
+
+// creditLine 返回完整的署名
+func (m *ImageMetadata) creditLine() string {
+	return CopyrightInfo{Credit: m.Credit, Agency: m.Agency}.CreditLine()
+}
+
+// UnmarshalImageMetadata 解析 JSON 或 YAML 格式的元数据
+func UnmarshalImageMetadata(data []byte, format MetadataFormat) (*ImageMetadata, error) {
+	meta := &ImageMetadata{}
+	switch format {
+	case MetadataJSON:
+		if err := json.Unmarshal(data, meta); err != nil {
+			return nil, fmt.Errorf("解析 JSON 元数据失败: %v", err)
+		}
+	case MetadataYAML:
+		if err := unmarshalFlatYAML(data, meta); err != nil {
+			return nil, fmt.Errorf("解析 YAML 元数据失败: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持解析 %s 格式的元数据", format)
+	}
+	return meta, nil
+}
+
+// marshalFlatYAML 将结构体按 json 标签输出为单层 YAML
+// 每个值都以 JSON 形式写出，JSON 标量和数组同时也是合法的 YAML
+func marshalFlatYAML(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+
+	var buf bytes.Buffer
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, omitEmpty := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		value := rv.Field(i)
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("序列化字段 %s 失败: %v", name, err)
+		}
+		fmt.Fprintf(&buf, "%s: %s\n", name, encoded)
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalFlatYAML 解析 marshalFlatYAML 输出的单层 YAML
+func unmarshalFlatYAML(data []byte, v interface{}) error {
+	fields := make(map[string]json.RawMessage)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("无效的行: %s", line)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || value == "~" {
+			continue
+		}
+		if !json.Valid([]byte(value)) {
+			// 未加引号的字符串
+			quoted, _ := json.Marshal(value)
+			value = string(quoted)
+		}
+		fields[strings.TrimSpace(key)] = json.RawMessage(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
+
+// jsonFieldName 返回字段的 json 名称以及是否设置了 omitempty
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(options, "omitempty")
+}
+
+// MetadataFilename 返回元数据文件的保存路径
+// XMP 按照通用的边车文件约定与图片同名，其他格式使用文件名生成器的 JSON 路径并替换扩展名
+func (bis *BingImageStorage) MetadataFilename(imageData *ImageData, imagePath string, format MetadataFormat) string {
+	if format == MetadataXMP {
+		return strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + format.Ext()
+	}
+
+	jsonPath := bis.Generator.GenerateJsonFilename(imageData, bis.OutputDir)
+	return strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath)) + format.Ext()
+}
+
+// SaveMetadata 按指定格式保存规范化元数据
+func (bis *BingImageStorage) SaveMetadata(meta *ImageMetadata, imageData *ImageData, imagePath string, format MetadataFormat) (string, error) {
+	bis.Logger.Info("保存 %s 元数据...", strings.ToUpper(format.String()))
+
+	// 生成文件路径
+	filePath := bis.MetadataFilename(imageData, imagePath, format)
+
+	// 记录图片相对于元数据文件的路径
+	if rel, err := filepath.Rel(filepath.Dir(filePath), imagePath); err == nil {
+		meta.Image = filepath.ToSlash(rel)
+	} else {
+		meta.Image = filepath.Base(imagePath)
+	}
+
+	data, err := meta.Marshal(format)
+	if err != nil {
+		bis.Logger.Error("序列化元数据失败: %v", err)
+		return "", fmt.Errorf("序列化元数据失败: %v", err)
+	}
+
+	// 保存数据
+	if err := bis.Storage.Save(data, filePath); err != nil {
+		return "", err
+	}
+
+	bis.updateAliases(imageData, filePath, data, false)
+	return filePath, nil
+}
@@ -0,0 +1,301 @@
+package bingclient
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// DefaultPaletteSize 是默认提取的颜色数量
+const DefaultPaletteSize = 8
+
+// 提取调色板前将图片缩小到的最大边长
+const paletteSampleSize = 128
+
+// PaletteColor 是调色板中的一种颜色
+type PaletteColor struct {
+	Color  color.RGBA // 颜色
+	Weight float64    // 占比 (0-1)
+}
+
+// Hex 返回 #rrggbb 形式的颜色
+func (c PaletteColor) Hex() string {
+	return colorHex(c.Color)
+}
+
+// Palette 是从图片中提取的调色板，按占比从高到低排列
+type Palette struct {
+	Colors []PaletteColor
+}
+
+// ExtractPalette 使用中位切分算法提取图片中最具代表性的 size 种颜色
+func ExtractPalette(img image.Image, size int) *Palette {
+	if size <= 0 {
+		size = DefaultPaletteSize
+	}
+
+	pixels := samplePixels(img)
+	if len(pixels) == 0 {
+		return &Palette{}
+	}
+
+	// 中位切分: 每次将颜色范围最大的桶沿最长的通道从中位数处切开
+	boxes := []colorBox{{pixels: pixels}}
+	for len(boxes) < size {
+		index, channel, span := -1, 0, 0
+		for i, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+			if c, s := box.widestChannel(); s > span {
+				index, channel, span = i, c, s
+			}
+		}
+		if index < 0 {
+			break
+		}
+
+		box := boxes[index]
+		sort.Slice(box.pixels, func(a, b int) bool {
+			return box.pixels[a][channel] < box.pixels[b][channel]
+		})
+		mid := len(box.pixels) / 2
+		boxes[index] = colorBox{pixels: box.pixels[:mid]}
+		boxes = append(boxes, colorBox{pixels: box.pixels[mid:]})
+	}
+
+	palette := &Palette{Colors: make([]PaletteColor, 0, len(boxes))}
+	for _, box := range boxes {
+		palette.Colors = append(palette.Colors, PaletteColor{
+			Color:  box.average(),
+			Weight: float64(len(box.pixels)) / float64(len(pixels)),
+		})
+	}
+	sort.SliceStable(palette.Colors, func(a, b int) bool {
+		return palette.Colors[a].Weight > palette.Colors[b].Weight
+	})
+	return palette
+}
+
+// Dominant 返回占比最高的颜色
+func (p *Palette) Dominant() color.RGBA {
+	if len(p.Colors) == 0 {
+		return color.RGBA{A: 255}
+	}
+	return p.Colors[0].Color
+}
+
+// Accent 返回醒目的强调色: 在占比不太低的颜色中选择饱和度最高的一种
+func (p *Palette) Accent() color.RGBA {
+	if len(p.Colors) == 0 {
+		return color.RGBA{A: 255}
+	}
+
+	best, bestScore := p.Colors[0].Color, -1.0
+	for _, c := range p.Colors {
+		_, s, l := rgbToHSL(c.Color)
+		// 过暗或过亮的颜色不适合作为强调色
+		score := s * (1 - math.Abs(l-0.5)*2) * math.Sqrt(c.Weight)
+		if score > bestScore {
+			best, bestScore = c.Color, score
+		}
+	}
+	return best
+}
+
+// Hex 返回全部颜色的 #rrggbb 表示
+func (p *Palette) Hex() []string {
+	hex := make([]string, len(p.Colors))
+	for i, c := range p.Colors {
+		hex[i] = c.Hex()
+	}
+	return hex
+}
+
+// IsDark 判断图片整体是否偏暗（按占比加权的相对亮度）
+func (p *Palette) IsDark() bool {
+	var luminance float64
+	for _, c := range p.Colors {
+		luminance += relativeLuminance(c.Color) * c.Weight
+	}
+	return luminance < 0.4
+}
+
+// colorBox 是中位切分中的一个颜色桶
+type colorBox struct {
+	pixels [][3]uint8
+}
+
+// widestChannel 返回取值范围最大的通道及其范围
+func (b colorBox) widestChannel() (int, int) {
+	lo := [3]uint8{255, 255, 255}
+	hi := [3]uint8{}
+	for _, p := range b.pixels {
+		for c := 0; c < 3; c++ {
+			lo[c] = min(lo[c], p[c])
+			hi[c] = max(hi[c], p[c])
+		}
+	}
+	channel, span := 0, -1
+	for c := 0; c < 3; c++ {
+		if s := int(hi[c]) - int(lo[c]); s > span {
+			channel, span = c, s
+		}
+	}
+	return channel, span
+}
+
+// average 返回桶内颜色的平均值
+func (b colorBox) average() color.RGBA {
+	var sum [3]int
+	for _, p := range b.pixels {
+		for c := 0; c < 3; c++ {
+			sum[c] += int(p[c])
+		}
+	}
+	n := max(1, len(b.pixels))
+	return color.RGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), 255}
+}
+
+// samplePixels 将图片缩小后返回全部像素
+func samplePixels(img image.Image) [][3]uint8 {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil
+	}
+
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > paletteSampleSize || h > paletteSampleSize {
+		if w >= h {
+			w, h = paletteSampleSize, max(1, h*paletteSampleSize/w)
+		} else {
+			w, h = max(1, w*paletteSampleSize/h), paletteSampleSize
+		}
+	}
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, bounds, draw.Src, nil)
+
+	pixels := make([][3]uint8, 0, w*h)
+	for i := 0; i+3 < len(small.Pix); i += 4 {
+		pixels = append(pixels, [3]uint8{small.Pix[i], small.Pix[i+1], small.Pix[i+2]})
+	}
+	return pixels
+}
+
+// colorHex 返回 #rrggbb 形式的颜色
+func colorHex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ParseHexColor 解析 #rrggbb 形式的颜色
+func ParseHexColor(value string) (color.RGBA, error) {
+	var c color.RGBA
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) != 6 {
+		return c, fmt.Errorf("无效的颜色: %s", value)
+	}
+	if _, err := fmt.Sscanf(value, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("无效的颜色: %s", value)
+	}
+	c.A = 255
+	return c, nil
+}
+
+// relativeLuminance 返回 WCAG 定义的相对亮度 (0-1)
+func relativeLuminance(c color.RGBA) float64 {
+	linear := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// rgbToHSL 将颜色转换为色相 (0-360)、饱和度和亮度 (0-1)
+func rgbToHSL(c color.RGBA) (float64, float64, float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi := math.Max(r, math.Max(g, b))
+	lo := math.Min(r, math.Min(g, b))
+	l := (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+
+	d := hi - lo
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// hslToRGB 将色相 (0-360)、饱和度和亮度 (0-1) 转换为颜色
+func hslToRGB(h, s, l float64) color.RGBA {
+	s = math.Max(0, math.Min(1, s))
+	l = math.Max(0, math.Min(1, l))
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	to8 := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v+m)) * 255))
+	}
+	return color.RGBA{to8(r), to8(g), to8(b), 255}
+}
+
+// PaletteAnalyzer 提取调色板并写入元数据
+type PaletteAnalyzer struct {
+	Size int // 提取的颜色数量
+}
+
+// NewPaletteAnalyzer 创建一个调色板分析器
+func NewPaletteAnalyzer(size int) *PaletteAnalyzer {
+	if size <= 0 {
+		size = DefaultPaletteSize
+	}
+	return &PaletteAnalyzer{Size: size}
+}
+
+// Analyze 提取调色板并写入元数据的 Dominant、Accent 和 Palette 字段
+func (a *PaletteAnalyzer) Analyze(src image.Image, meta *ImageMetadata) error {
+	if meta == nil {
+		return nil
+	}
+	palette := ExtractPalette(src, a.Size)
+	meta.Dominant = colorHex(palette.Dominant())
+	meta.Accent = colorHex(palette.Accent())
+	meta.Palette = palette.Hex()
+	return nil
+}
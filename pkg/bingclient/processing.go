@@ -0,0 +1,199 @@
+package bingclient
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultJPEGQuality 是派生图片的默认 JPEG 质量
+const DefaultJPEGQuality = 85
+
+// VariantKind 表示派生图片的类型
+type VariantKind int
+
+const (
+	// VariantThumbnail 缩略图
+	VariantThumbnail VariantKind = iota
+	// VariantCrop 按目标分辨率裁剪的图片
+	VariantCrop
+	// VariantCaption 叠加了说明文字的图片
+	VariantCaption
+	// VariantSpan 跨越多台显示器的图片
+	VariantSpan
+)
+
+// String 返回类型名称
+func (k VariantKind) String() string {
+	switch k {
+	case VariantThumbnail:
+		return "thumbnail"
+	case VariantCrop:
+		return "crop"
+	case VariantCaption:
+		return "caption"
+	case VariantSpan:
+		return "span"
+	default:
+		return "unknown"
+	}
+}
+
+// ImageVariant 是处理器生成的一张派生图片
+type ImageVariant struct {
+	Kind  VariantKind // 类型
+	Name  string      // 名称，如 320w，用于日志和结果记录
+	Path  string      // 保存路径
+	Image image.Image // 图片内容
+}
+
+// ImageProcessor 是图片处理器接口，根据已保存的壁纸生成派生图片
+type ImageProcessor interface {
+	// Process 处理原图，imagePath 为原图的保存路径，用于生成派生图片的路径
+	Process(src image.Image, imageData *ImageData, imagePath string) ([]*ImageVariant, error)
+}
+
+// ImageAnalyzer 是图片分析器接口，分析原图并将结果写入元数据
+type ImageAnalyzer interface {
+	// Analyze 分析原图，meta 可能为 nil
+	Analyze(src image.Image, meta *ImageMetadata) error
+}
+
+// SavedVariant 记录已保存的派生图片
+type SavedVariant struct {
+	Kind   VariantKind // 类型
+	Name   string      // 名称
+	Path   string      // 保存路径
+	Width  int         // 宽度
+	Height int         // 高度
+}
+
+// ImagePipeline 在原图保存后依次运行图片分析器和处理器，并通过存储保存生成的派生图片
+// 原图只解码一次，由全部分析器和处理器共享
+type ImagePipeline struct {
+	Processors []ImageProcessor // 图片处理器
+	Analyzers  []ImageAnalyzer  // 图片分析器
+	Quality    int              // JPEG 编码质量 (1-100)
+	Logger     Logger           // 日志记录器
+}
+
+// NewImagePipeline 创建一个新的图片处理流水线
+func NewImagePipeline(logger Logger, processors ...ImageProcessor) *ImagePipeline {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	return &ImagePipeline{
+		Processors: processors,
+		Quality:    DefaultJPEGQuality,
+		Logger:     logger,
+	}
+}
+
+// AddProcessor 添加图片处理器，返回流水线本身以便链式调用
+func (p *ImagePipeline) AddProcessor(processor ImageProcessor) *ImagePipeline {
+	p.Processors = append(p.Processors, processor)
+	return p
+}
+
+// AddAnalyzer 添加图片分析器，返回流水线本身以便链式调用
+func (p *ImagePipeline) AddAnalyzer(analyzer ImageAnalyzer) *ImagePipeline {
+	p.Analyzers = append(p.Analyzers, analyzer)
+	return p
+}
+
+// Empty 判断流水线中是否没有任何分析器和处理器
+func (p *ImagePipeline) Empty() bool {
+	return len(p.Processors) == 0 && len(p.Analyzers) == 0
+}
+
+// Run 解码原图并运行全部分析器和处理器，分析结果写入 meta
+// 单个分析器、处理器或派生图片失败不会影响其他步骤，所有错误会一并返回
+func (p *ImagePipeline) Run(data []byte, imageData *ImageData, meta *ImageMetadata, imagePath string, storage Storage) ([]*SavedVariant, []error) {
+	if p.Empty() {
+		return nil, nil
+	}
+
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		p.Logger.Error("解码图片失败: %v", err)
+		return nil, []error{fmt.Errorf("解码图片失败: %v", err)}
+	}
+
+	var saved []*SavedVariant
+	var errs []error
+	for _, analyzer := range p.Analyzers {
+		if err := analyzer.Analyze(src, meta); err != nil {
+			p.Logger.Warning("图片分析失败: %v", err)
+			errs = append(errs, err)
+		}
+	}
+
+	for _, processor := range p.Processors {
+		variants, err := processor.Process(src, imageData, imagePath)
+		if err != nil {
+			p.Logger.Warning("图片处理失败: %v", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, variant := range variants {
+			if err := p.save(variant, storage); err != nil {
+				p.Logger.Warning("保存派生图片 %s 失败: %v", variant.Name, err)
+				errs = append(errs, fmt.Errorf("保存派生图片 %s 失败: %v", variant.Name, err))
+				continue
+			}
+
+			bounds := variant.Image.Bounds()
+			saved = append(saved, &SavedVariant{
+				Kind:   variant.Kind,
+				Name:   variant.Name,
+				Path:   variant.Path,
+				Width:  bounds.Dx(),
+				Height: bounds.Dy(),
+			})
+			p.Logger.Info("已生成%s %s: %s", variantKindLabel(variant.Kind), variant.Name, variant.Path)
+		}
+	}
+
+	return saved, errs
+}
+
+// save 编码并保存单张派生图片
+func (p *ImagePipeline) save(variant *ImageVariant, storage Storage) error {
+	quality := p.Quality
+	if quality <= 0 || quality > 100 {
+		quality = DefaultJPEGQuality
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, variant.Image, &jpeg.Options{Quality: quality}); err != nil {
+		return fmt.Errorf("编码 JPEG 失败: %v", err)
+	}
+	return storage.Save(buf.Bytes(), variant.Path)
+}
+
+// variantKindLabel 返回派生图片类型的中文名称
+func variantKindLabel(kind VariantKind) string {
+	switch kind {
+	case VariantThumbnail:
+		return "缩略图"
+	case VariantCrop:
+		return "裁剪图"
+	case VariantCaption:
+		return "文字图"
+	case VariantSpan:
+		return "跨屏图"
+	default:
+		return "派生图片"
+	}
+}
+
+// variantPath 返回派生图片的保存路径：<原图目录>/<subdir>/<原图名称>_<suffix>.jpg
+func variantPath(imagePath, subdir, suffix string) string {
+	base := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+	return filepath.Join(filepath.Dir(imagePath), subdir, fmt.Sprintf("%s_%s.jpg", base, suffix))
+}
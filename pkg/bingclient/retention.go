@@ -0,0 +1,316 @@
+package bingclient
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FavoritesFilename 是壁纸目录中收藏列表的默认文件名
+const FavoritesFilename = "favorites.txt"
+
+// GFS 轮换的默认参数：每天保留一个月，每周保留一年
+const (
+	DefaultGFSDaily  = 30
+	DefaultGFSWeekly = 52
+)
+
+// RetentionPolicy 是本地壁纸的保留策略
+// 按天数和 GFS（祖父-父-子）规则保留的壁纸取并集，未被任何规则保留的壁纸会被删除；
+// 未设置任何保留规则时全部保留。之后如果总大小仍超过 MaxBytes，从最旧的壁纸开始删除。
+// 收藏的壁纸始终保留，也不计入删除顺序。
+type RetentionPolicy struct {
+	KeepDays    int   // 保留最近 N 天的全部壁纸，0 表示不使用该规则
+	KeepDaily   int   // GFS: 最近 N 天中每天保留一张（每个市场）
+	KeepWeekly  int   // GFS: 最近 N 周中每周保留一张（每个市场）
+	KeepMonthly int   // GFS: 最近 N 个月中每月保留一张（每个市场）
+	MaxBytes    int64 // 壁纸及其相关文件的总大小上限，0 表示不限制
+
+	Favorites []string // 收藏的壁纸，可以是日期 (YYYYMMDD 或 YYYY-MM-DD)、文件名或相对路径
+}
+
+// Empty 判断策略是否没有任何规则
+func (p *RetentionPolicy) Empty() bool {
+	return !p.hasKeepRules() && p.MaxBytes <= 0
+}
+
+// hasKeepRules 判断是否设置了按时间保留的规则
+func (p *RetentionPolicy) hasKeepRules() bool {
+	return p.KeepDays > 0 || p.KeepDaily > 0 || p.KeepWeekly > 0 || p.KeepMonthly > 0
+}
+
+// ParseRetentionPolicy 解析以逗号分隔的保留策略，如 keep-days=30,max-size=10GB,gfs
+// 支持的键: keep-days、keep-daily、keep-weekly、keep-monthly、max-size，
+// 以及 gfs（等同于 keep-daily=30,keep-weekly=52）
+func ParseRetentionPolicy(spec string) (*RetentionPolicy, error) {
+	policy := &RetentionPolicy{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "gfs" {
+			policy.KeepDaily = DefaultGFSDaily
+			policy.KeepWeekly = DefaultGFSWeekly
+			continue
+		}
+		if key == "max-size" {
+			size, err := ParseByteSize(value)
+			if err != nil {
+				return nil, err
+			}
+			policy.MaxBytes = size
+			continue
+		}
+
+		var target *int
+		switch key {
+		case "keep-days":
+			target = &policy.KeepDays
+		case "keep-daily":
+			target = &policy.KeepDaily
+		case "keep-weekly":
+			target = &policy.KeepWeekly
+		case "keep-monthly":
+			target = &policy.KeepMonthly
+		default:
+			return nil, fmt.Errorf("未知的保留策略: %s (可选 keep-days, keep-daily, keep-weekly, keep-monthly, max-size, gfs)", key)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("无效的保留策略取值: %s", part)
+		}
+		*target = n
+	}
+
+	if policy.Empty() {
+		return nil, fmt.Errorf("保留策略为空: %s", spec)
+	}
+	return policy, nil
+}
+
+// ParseByteSize 解析带单位的大小，如 500MB、10GB、1.5G，单位按 1024 换算，无单位时为字节
+func ParseByteSize(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "IB"), "B")
+
+	multiplier := int64(1)
+	if text != "" {
+		switch text[len(text)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			text = text[:len(text)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的大小: %s (如 500MB、10GB)", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatByteSize 将字节数格式化为易读的形式
+func FormatByteSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// LoadFavorites 读取收藏列表，每行一项，# 开头的行为注释；文件不存在时返回空列表
+func LoadFavorites(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取收藏列表失败: %v", err)
+	}
+	defer file.Close()
+
+	var favorites []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		favorites = append(favorites, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取收藏列表失败: %v", err)
+	}
+	return favorites, nil
+}
+
+// PruneCandidate 是保留策略决定删除的壁纸
+type PruneCandidate struct {
+	Wallpaper *LocalWallpaper // 壁纸
+	Reason    string          // 删除原因
+	Size      int64           // 壁纸及其相关文件的总大小
+}
+
+// RetentionPlan 是保留策略的执行计划
+type RetentionPlan struct {
+	Keep   []*LocalWallpaper // 保留的壁纸
+	Delete []*PruneCandidate // 删除的壁纸，按日期从旧到新排列
+}
+
+// Plan 根据保留策略计算需要保留和删除的壁纸，now 用于计算天数，baseDir 用于匹配收藏列表中的相对路径
+// 无法确定日期的壁纸始终保留
+func (p *RetentionPolicy) Plan(wallpapers []*LocalWallpaper, baseDir string, now time.Time) *RetentionPlan {
+	today := dateOnly(now)
+
+	type entry struct {
+		wallpaper *LocalWallpaper
+		date      time.Time
+		size      int64
+		keep      bool
+		favorite  bool
+		undated   bool
+	}
+
+	favorites := make(map[string]bool)
+	for _, favorite := range p.Favorites {
+		favorites[normalizeFavorite(favorite)] = true
+	}
+
+	// 按日期从新到旧排列，GFS 每个时间段保留最新的一张
+	entries := make([]*entry, 0, len(wallpapers))
+	for _, w := range wallpapers {
+		e := &entry{wallpaper: w, size: w.DiskUsage(), keep: !p.hasKeepRules()}
+		date, err := time.ParseInLocation(bingDateLayout, w.Date(), time.Local)
+		if err != nil {
+			e.undated, e.keep = true, true
+		}
+		e.date = date
+
+		rel, _ := filepath.Rel(baseDir, w.Path)
+		e.favorite = favorites[w.Date()] || favorites[filepath.Base(w.Path)] || favorites[filepath.ToSlash(rel)]
+		if e.favorite {
+			e.keep = true
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].date.After(entries[j].date)
+	})
+
+	// 按天数保留
+	if p.KeepDays > 0 {
+		cutoff := today.AddDate(0, 0, -(p.KeepDays - 1))
+		for _, e := range entries {
+			if !e.undated && !e.date.Before(cutoff) {
+				e.keep = true
+			}
+		}
+	}
+
+	// GFS: 每个时间段、每个市场保留最新的一张
+	rules := []struct {
+		count  int
+		cutoff time.Time
+		bucket func(time.Time) string
+	}{
+		{p.KeepDaily, today.AddDate(0, 0, -(p.KeepDaily - 1)), func(t time.Time) string { return t.Format(bingDateLayout) }},
+		{p.KeepWeekly, weekStart(today).AddDate(0, 0, -7*(p.KeepWeekly-1)), func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.KeepMonthly, time.Date(today.Year(), today.Month()-time.Month(p.KeepMonthly-1), 1, 0, 0, 0, 0, time.Local), func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, rule := range rules {
+		if rule.count <= 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, e := range entries {
+			if e.undated || e.date.Before(rule.cutoff) {
+				continue
+			}
+			key := rule.bucket(e.date) + "|" + wallpaperMarket(e.wallpaper)
+			if !seen[key] {
+				seen[key] = true
+				e.keep = true
+			}
+		}
+	}
+
+	plan := &RetentionPlan{}
+	var total int64
+	for _, e := range entries {
+		if e.keep {
+			total += e.size
+		}
+	}
+
+	// 从旧到新处理，超出大小上限时删除最旧的非收藏壁纸
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch {
+		case !e.keep:
+			plan.Delete = append(plan.Delete, &PruneCandidate{Wallpaper: e.wallpaper, Reason: "超出保留期限", Size: e.size})
+		case p.MaxBytes > 0 && total > p.MaxBytes && !e.favorite && !e.undated:
+			total -= e.size
+			plan.Delete = append(plan.Delete, &PruneCandidate{Wallpaper: e.wallpaper, Reason: "超出大小上限", Size: e.size})
+		default:
+			plan.Keep = append(plan.Keep, e.wallpaper)
+		}
+	}
+	return plan
+}
+
+// normalizeFavorite 将收藏项规范化，日期统一为 YYYYMMDD
+func normalizeFavorite(value string) string {
+	value = filepath.ToSlash(strings.TrimSpace(value))
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Format(bingDateLayout)
+	}
+	return value
+}
+
+// wallpaperMarket 返回壁纸的市场代码，没有元数据时返回空字符串
+func wallpaperMarket(w *LocalWallpaper) string {
+	if w.Metadata == nil {
+		return ""
+	}
+	return w.Metadata.Market
+}
+
+// dateOnly 返回本地时区中当天的零点
+func dateOnly(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// weekStart 返回所在 ISO 周的周一
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,184 @@
+package bingclient
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxFilenameBytes 是文件名组件的默认最大字节数
+// 常见文件系统限制单个文件名为 255 字节，这里为日期前缀和扩展名预留空间
+const DefaultMaxFilenameBytes = 200
+
+// Windows 保留的设备名称，无论扩展名如何都不能作为文件名
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// 文件名中需要替换或删除的字符
+var filenameReplacements = map[rune]string{
+	' ':  "_",
+	'/':  "-",
+	'\\': "-",
+	':':  "-",
+	'|':  "-",
+	'?':  "",
+	'*':  "",
+	'<':  "",
+	'>':  "",
+	'"':  "'",
+}
+
+// 常见非 ASCII 字符的 ASCII 音译
+var asciiTransliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "Ae", 'Å': "A", 'Æ': "AE", 'Ç': "C",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "Oe", 'Ø': "O",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "Ue", 'Ý': "Y", 'Þ': "Th", 'ß': "ss",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "ae", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ð': "d", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "oe", 'ø': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "ue", 'ý': "y", 'þ': "th", 'ÿ': "y",
+	'Ā': "A", 'ā': "a", 'Ă': "A", 'ă': "a", 'Ą': "A", 'ą': "a", 'Ć': "C", 'ć': "c",
+	'Č': "C", 'č': "c", 'Ď': "D", 'ď': "d", 'Đ': "D", 'đ': "d", 'Ē': "E", 'ē': "e",
+	'Ė': "E", 'ė': "e", 'Ę': "E", 'ę': "e", 'Ě': "E", 'ě': "e", 'Ğ': "G", 'ğ': "g",
+	'Ī': "I", 'ī': "i", 'Į': "I", 'į': "i", 'İ': "I", 'ı': "i", 'Ķ': "K", 'ķ': "k",
+	'Ĺ': "L", 'ĺ': "l", 'Ľ': "L", 'ľ': "l", 'Ł': "L", 'ł': "l", 'Ń': "N", 'ń': "n",
+	'Ň': "N", 'ň': "n", 'Ō': "O", 'ō': "o", 'Ő': "O", 'ő': "o", 'Œ': "OE", 'œ': "oe",
+	'Ŕ': "R", 'ŕ': "r", 'Ř': "R", 'ř': "r", 'Ś': "S", 'ś': "s", 'Ş': "S", 'ş': "s",
+	'Š': "S", 'š': "s", 'Ţ': "T", 'ţ': "t", 'Ť': "T", 'ť': "t", 'Ū': "U", 'ū': "u",
+	'Ů': "U", 'ů': "u", 'Ű': "U", 'ű': "u", 'Ų': "U", 'ų': "u", 'Ÿ': "Y", 'Ź': "Z",
+	'ź': "z", 'Ż': "Z", 'ż': "z", 'Ž': "Z", 'ž': "z", 'Ș': "S", 'ș': "s", 'Ț': "T",
+	'ț': "t",
+	'‘': "'", '’': "'", '‚': "'", '“': "'", '”': "'", '„': "'", '«': "'", '»': "'",
+	'–': "-", '—': "-", '…': "...", '·': "-", '，': "-", '、': "-", '：': "-", '（': "(",
+	'）': ")", '。': ".", '！': "!", '？': "", '　': "_",
+}
+
+// FilenameSanitizer 用于清理文件名组件，保证其在各平台上合法且不会逃逸出输出目录
+type FilenameSanitizer struct {
+	MaxBytes  int  // 最大字节数，按 UTF-8 字符边界截断，0 表示不限制
+	ASCIIOnly bool // 是否将非 ASCII 字符音译为 ASCII，无法音译的字符会被删除
+}
+
+// NewFilenameSanitizer 创建一个使用默认配置的文件名清理器
+func NewFilenameSanitizer() *FilenameSanitizer {
+	return &FilenameSanitizer{
+		MaxBytes: DefaultMaxFilenameBytes,
+	}
+}
+
+// SanitizeFilename 使用默认配置清理文件名组件
+func SanitizeFilename(name string) string {
+	return NewFilenameSanitizer().Sanitize(name)
+}
+
+// Sanitize 清理单个文件名组件
+// 会删除控制字符、替换路径分隔符等保留字符、去掉开头的点和结尾的点与空格、
+// 避开 Windows 保留名称，并限制字节长度；结果可能为空字符串
+func (s *FilenameSanitizer) Sanitize(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	if s.ASCIIOnly {
+		name = TransliterateASCII(name)
+	}
+
+	var builder strings.Builder
+	for _, r := range name {
+		if replacement, ok := filenameReplacements[r]; ok {
+			builder.WriteString(replacement)
+			continue
+		}
+		if unicode.IsSpace(r) {
+			builder.WriteString("_")
+			continue
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	name = builder.String()
+
+	// 合并连续的下划线
+	for strings.Contains(name, "__") {
+		name = strings.ReplaceAll(name, "__", "_")
+	}
+
+	// 开头的点会生成隐藏文件或 .. 路径
+	name = strings.TrimLeft(name, ". _")
+	name = trimFilenameEnd(name)
+
+	if s.MaxBytes > 0 && len(name) > s.MaxBytes {
+		name = trimFilenameEnd(TruncateUTF8(name, s.MaxBytes))
+	}
+
+	// 避开 Windows 保留名称（如 CON、NUL.txt）
+	base := name
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if windowsReservedNames[strings.ToUpper(base)] {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// trimFilenameEnd 去掉 Windows 不允许的结尾点和空格
+func trimFilenameEnd(name string) string {
+	return strings.TrimRight(name, ". _")
+}
+
+// TruncateUTF8 将字符串截断到不超过 maxBytes 字节，且不会截断多字节字符
+func TruncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// TransliterateASCII 将常见的非 ASCII 字符音译为 ASCII，无法音译的字符会被删除
+func TransliterateASCII(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			builder.WriteRune(r)
+			continue
+		}
+		if replacement, ok := asciiTransliterations[r]; ok {
+			builder.WriteString(replacement)
+			continue
+		}
+		if unicode.IsSpace(r) {
+			builder.WriteByte(' ')
+		}
+	}
+	return builder.String()
+}
+
+// SafeJoin 将相对路径拼接到基础目录下，拒绝绝对路径和逃逸出基础目录的路径
+func SafeJoin(baseDir, name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("文件名不能为空")
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "\\") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("文件名必须是相对路径: %s", name)
+	}
+
+	joined := filepath.Join(baseDir, name)
+	rel, err := filepath.Rel(baseDir, joined)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("文件路径超出了输出目录: %s", name)
+	}
+	return joined, nil
+}
@@ -0,0 +1,109 @@
+package bingclient
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFilenameSanitizerSanitize(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		maxBytes  int
+		asciiOnly bool
+		want      string
+	}{
+		{name: "普通文件名", input: "Lake Bled", want: "Lake_Bled"},
+		{name: "上级目录", input: "../x", want: "-x"},
+		{name: "多级上级目录", input: "../../etc/passwd", want: "-..-etc-passwd"},
+		{name: "只有点", input: "..", want: ""},
+		{name: "绝对路径", input: "/etc/passwd", want: "-etc-passwd"},
+		{name: "Windows 绝对路径", input: `C:\Windows\win.ini`, want: "C--Windows-win.ini"},
+		{name: "Windows 保留名称", input: "CON", want: "_CON"},
+		{name: "Windows 保留名称带扩展名", input: "CON.jpg", want: "_CON.jpg"},
+		{name: "Windows 保留名称小写", input: "nul.txt", want: "_nul.txt"},
+		{name: "以保留名称开头的普通名称", input: "CONSOLE.jpg", want: "CONSOLE.jpg"},
+		{name: "结尾的点和空格", input: "Lake Bled. . ", want: "Lake_Bled"},
+		{name: "开头的点", input: ".hidden", want: "hidden"},
+		{name: "保留字符", input: `Bled: <Island>? "*"|`, want: "Bled-_Island_''-"},
+		{name: "控制字符和格式字符", input: "a\x00b\tc\u200bd", want: "ab_cd"},
+		{name: "无效的 UTF-8", input: "a\xffb", want: "ab"},
+		{name: "合并连续的下划线", input: "a   b", want: "a_b"},
+		{name: "保留中文标点", input: "布莱德湖，斯洛文尼亚", want: "布莱德湖，斯洛文尼亚"},
+		{name: "音译中文标点", input: "布莱德湖，斯洛文尼亚", asciiOnly: true, want: "-"},
+		{name: "按字符边界截断多字节字符", input: "布莱德湖斯洛文尼亚", maxBytes: 10, want: "布莱德"},
+		{name: "截断后去掉结尾的下划线", input: "ab_cd_ef", maxBytes: 6, want: "ab_cd"},
+		{name: "不限制长度", input: "布莱德湖斯洛文尼亚", want: "布莱德湖斯洛文尼亚"},
+		{name: "音译为 ASCII", input: "Zürich Straße 湖", asciiOnly: true, want: "Zuerich_Strasse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &FilenameSanitizer{MaxBytes: tt.maxBytes, ASCIIOnly: tt.asciiOnly}
+			if got := s.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxBytes int
+		want     string
+	}{
+		{input: "abc", maxBytes: 5, want: "abc"},
+		{input: "abcdef", maxBytes: 3, want: "abc"},
+		{input: "布莱德", maxBytes: 9, want: "布莱德"},
+		{input: "布莱德", maxBytes: 8, want: "布莱"},
+		{input: "布莱德", maxBytes: 2, want: ""},
+		{input: "a😀b", maxBytes: 4, want: "a"},
+	}
+
+	for _, tt := range tests {
+		if got := TruncateUTF8(tt.input, tt.maxBytes); got != tt.want {
+			t.Errorf("TruncateUTF8(%q, %d) = %q, want %q", tt.input, tt.maxBytes, got, tt.want)
+		}
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	base := filepath.FromSlash("/walls")
+	tests := []struct {
+		name  string
+		input string
+		want  string // 为空表示应返回错误
+	}{
+		{name: "文件名", input: "a.jpg", want: "/walls/a.jpg"},
+		{name: "子目录", input: "2026/10/a.jpg", want: "/walls/2026/10/a.jpg"},
+		{name: "目录内的上级目录", input: "2026/../a.jpg", want: "/walls/a.jpg"},
+		{name: "上级目录", input: "../x"},
+		{name: "经由子目录逃逸", input: "a/../../x"},
+		{name: "只有上级目录", input: ".."},
+		{name: "基础目录本身", input: "."},
+		{name: "回到基础目录", input: "a/.."},
+		{name: "绝对路径", input: "/etc/passwd"},
+		{name: "反斜杠开头", input: `\x`},
+		{name: "空文件名", input: ""},
+		{name: "空白文件名", input: "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafeJoin(base, tt.input)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("SafeJoin(%q) = %q，应返回错误", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.FromSlash(tt.want); got != want {
+				t.Errorf("SafeJoin(%q) = %q, want %q", tt.input, got, want)
+			}
+		})
+	}
+}
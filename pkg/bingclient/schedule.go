@@ -0,0 +1,235 @@
+package bingclient
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 是定时任务的调度计划
+type Schedule interface {
+	// Next 返回晚于 t 的下一次执行时间
+	Next(t time.Time) time.Time
+}
+
+// IntervalSchedule 按固定间隔执行
+type IntervalSchedule struct {
+	Interval time.Duration // 执行间隔
+}
+
+// Next 返回 t 之后一个间隔的时间
+func (s IntervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.Interval)
+}
+
+// String 返回调度计划的描述
+func (s IntervalSchedule) String() string {
+	return "每 " + s.Interval.String()
+}
+
+// CronSchedule 是标准 5 字段 cron 表达式（分 时 日 月 周）描述的调度计划
+// 支持 *、数字、范围 (a-b)、列表 (a,b) 和步长 (*/n、a-b/n)，星期中 0 和 7 都表示周日；
+// 与 cron 一致，日和周都不是 * 时，满足其中任意一个即可
+type CronSchedule struct {
+	expr       string
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	domStar    bool
+	dowStar    bool
+	location   *time.Location
+}
+
+// cron 表达式的预定义别名
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCronSchedule 解析 cron 表达式，如 "5 8 * * *" 或 "@daily"，loc 为 nil 时使用本地时区
+func ParseCronSchedule(expr string, loc *time.Location) (*CronSchedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	spec := strings.TrimSpace(expr)
+	if alias, ok := cronAliases[strings.ToLower(spec)]; ok {
+		spec = alias
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("无效的 cron 表达式: %q (需要 5 个字段: 分 时 日 月 周)", expr)
+	}
+
+	s := &CronSchedule{expr: expr, location: loc}
+	bounds := []struct {
+		name     string
+		min, max int
+		target   *uint64
+	}{
+		{"分钟", 0, 59, &s.minute},
+		{"小时", 0, 23, &s.hour},
+		{"日期", 1, 31, &s.dayOfMonth},
+		{"月份", 1, 12, &s.month},
+		{"星期", 0, 7, &s.dayOfWeek},
+	}
+	for i, b := range bounds {
+		bits, err := parseCronField(fields[i], b.min, b.max)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 cron 表达式 %q: %s字段%v", expr, b.name, err)
+		}
+		*b.target = bits
+	}
+
+	// 星期中的 7 等同于 0（周日）
+	if s.dayOfWeek&(1<<7) != 0 {
+		s.dayOfWeek |= 1
+	}
+	s.domStar = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	s.dowStar = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	return s, nil
+}
+
+// parseCronField 将 cron 字段解析为位图
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("步长无效: %q", part)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeText == "*":
+			lo, hi = min, max
+		case strings.Contains(rangeText, "-"):
+			loText, hiText, _ := strings.Cut(rangeText, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(loText)
+			hi, err2 = strconv.Atoi(hiText)
+			if err1 != nil || err2 != nil || lo > hi {
+				return 0, fmt.Errorf("范围无效: %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(rangeText)
+			if err != nil {
+				return 0, fmt.Errorf("取值无效: %q", part)
+			}
+			lo, hi = n, n
+			// 与 cron 一致，a/n 表示从 a 开始到最大值
+			if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max {
+			return 0, fmt.Errorf("取值超出范围 %d-%d: %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next 返回晚于 t 的下一个匹配时间（精确到分钟），五年内没有匹配时返回零值
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay 判断日期是否匹配日和星期字段
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	dom := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dow := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// String 返回 cron 表达式
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// Jitter 返回 [0, max) 范围内的随机延迟，max 不大于 0 时返回 0
+// 多台机器使用相同的调度计划时，随机延迟可以避免同时请求 Bing
+func Jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// Backoff 是指数退避的重试间隔，每次失败后间隔翻倍，直到 Max
+type Backoff struct {
+	Initial time.Duration // 首次重试的间隔
+	Max     time.Duration // 最大间隔
+
+	attempt int
+}
+
+// NewBackoff 创建指数退避
+func NewBackoff(initial, max time.Duration) *Backoff {
+	return &Backoff{Initial: initial, Max: max}
+}
+
+// Next 返回下一次重试前的等待时间，并增加重试次数
+// 实际间隔在 [d/2, d) 之间随机，避免多个实例同时重试
+func (b *Backoff) Next() time.Duration {
+	d := b.Initial
+	for i := 0; i < b.attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	b.attempt++
+	return d/2 + Jitter(d/2)
+}
+
+// Attempts 返回连续失败的次数
+func (b *Backoff) Attempts() int {
+	return b.attempt
+}
+
+// Reset 在成功后重置重试次数
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
@@ -0,0 +1,93 @@
+package bingclient
+
+import (
+	"fmt"
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// SpanCanvasName 是 SpanProcessor 生成的整幅画布图片的名称
+const SpanCanvasName = "all"
+
+// SpanRegion 是跨屏画布中的一块区域，通常对应一台显示器
+type SpanRegion struct {
+	Name   string          // 名称，如显示器的输出名称 HDMI-1
+	Bounds image.Rectangle // 在画布中的位置（逻辑像素）
+	Size   Resolution      // 输出的分辨率（物理像素），为零时与 Bounds 的尺寸相同
+}
+
+// SpanProcessor 将一张壁纸铺满由多个区域组成的画布，再切分为每个区域的图片，
+// 使多台显示器拼接成一幅完整的画面；同时输出整幅画布，供支持跨屏显示的桌面使用
+type SpanProcessor struct {
+	Regions []SpanRegion   // 区域
+	Crop    *CropProcessor // 用于选择画布的构图位置，为 nil 时居中
+}
+
+// NewSpanProcessor 创建一个跨屏处理器，画布铺满时使用与 CropFill 相同的构图选择
+func NewSpanProcessor(regions ...SpanRegion) *SpanProcessor {
+	return &SpanProcessor{
+		Regions: append([]SpanRegion(nil), regions...),
+		Crop:    NewCropProcessor(CropFill),
+	}
+}
+
+// SpanPath 返回跨屏图的保存路径，如 variants/20261018_布莱德湖_span_HDMI-1.jpg
+func SpanPath(imagePath, name string) string {
+	return variantPath(imagePath, VariantDir, "span_"+SanitizeFilename(name))
+}
+
+// Process 生成整幅画布以及每个区域的图片
+func (s *SpanProcessor) Process(src image.Image, imageData *ImageData, imagePath string) ([]*ImageVariant, error) {
+	if len(s.Regions) == 0 {
+		return nil, fmt.Errorf("跨屏区域为空")
+	}
+	if src.Bounds().Dx() == 0 || src.Bounds().Dy() == 0 {
+		return nil, fmt.Errorf("图片尺寸无效: %dx%d", src.Bounds().Dx(), src.Bounds().Dy())
+	}
+
+	var canvasBounds image.Rectangle
+	for i, region := range s.Regions {
+		if region.Bounds.Empty() {
+			return nil, fmt.Errorf("跨屏区域 %s 的尺寸无效", region.Name)
+		}
+		if i == 0 {
+			canvasBounds = region.Bounds
+		} else {
+			canvasBounds = canvasBounds.Union(region.Bounds)
+		}
+	}
+
+	// 按画布的宽高比铺满，构图位置与 fill 裁剪一致
+	canvasSize := Resolution{Width: canvasBounds.Dx(), Height: canvasBounds.Dy()}
+	position := 0.5
+	if s.Crop != nil {
+		position = s.Crop.cropPosition(src, imageData, canvasSize)
+	}
+	canvas := cropToFill(src, canvasSize, position)
+
+	variants := []*ImageVariant{{
+		Kind:  VariantSpan,
+		Name:  fmt.Sprintf("%s_%s", SpanCanvasName, canvasSize),
+		Path:  SpanPath(imagePath, SpanCanvasName),
+		Image: canvas,
+	}}
+	for _, region := range s.Regions {
+		size := region.Size
+		if size.Width <= 0 || size.Height <= 0 {
+			size = Resolution{Width: region.Bounds.Dx(), Height: region.Bounds.Dy()}
+		}
+		// 区域坐标相对于画布左上角
+		rect := region.Bounds.Sub(canvasBounds.Min)
+		dst := image.NewRGBA(image.Rect(0, 0, size.Width, size.Height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), canvas, rect, draw.Src, nil)
+
+		variants = append(variants, &ImageVariant{
+			Kind:  VariantSpan,
+			Name:  fmt.Sprintf("%s_%s", region.Name, size),
+			Path:  SpanPath(imagePath, region.Name),
+			Image: dst,
+		})
+	}
+	return variants, nil
+}
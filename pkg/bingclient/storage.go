@@ -1,11 +1,11 @@
 package bingclient
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 // Storage 是存储接口，用于保存数据到不同类型的存储介质
@@ -39,20 +39,12 @@ func NewFileStorage(logger Logger) *FileStorage {
 }
 
 // Save 将数据保存到指定路径的文件
+// 数据先写入同一目录中的临时文件，成功后再重命名为目标文件，写入失败不会留下不完整的文件
 func (fs *FileStorage) Save(data []byte, path string) error {
 	fs.Logger.Debug("保存 %d 字节数据到文件: %s", len(data), path)
 
-	// 确保目录存在
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, fs.MkdirPermission); err != nil {
-		fs.Logger.Error("创建目录失败: %v", err)
-		return fmt.Errorf("创建目录失败: %v", err)
-	}
-
-	// 写入文件
-	if err := os.WriteFile(path, data, fs.FilePermission); err != nil {
-		fs.Logger.Error("写入文件失败: %v", err)
-		return fmt.Errorf("写入文件失败: %v", err)
+	if err := fs.writeAtomic(path, bytes.NewReader(data)); err != nil {
+		return err
 	}
 
 	fs.Logger.Info("成功保存数据到: %s", path)
@@ -60,41 +52,111 @@ func (fs *FileStorage) Save(data []byte, path string) error {
 }
 
 // SaveReader 从读取器保存数据到指定路径的文件
+// 与 Save 相同，读取器中的数据全部写入临时文件后才会替换目标文件
 func (fs *FileStorage) SaveReader(reader io.Reader, path string) error {
 	fs.Logger.Debug("从读取器保存数据到文件: %s", path)
 
+	counter := &countingReader{reader: reader}
+	if err := fs.writeAtomic(path, counter); err != nil {
+		return err
+	}
+
+	fs.Logger.Info("成功保存 %d 字节数据到: %s", counter.n, path)
+	return nil
+}
+
+// writeAtomic 将数据写入临时文件并同步到磁盘，然后原子地重命名为目标文件
+func (fs *FileStorage) writeAtomic(path string, reader io.Reader) error {
+	tmpPath, err := fs.writeTemp(path, reader)
+	if err != nil {
+		return err
+	}
+	return fs.commitTemp(tmpPath, path)
+}
+
+// writeTemp 将数据写入目标文件所在目录中的临时文件并同步到磁盘，返回临时文件路径
+// 写入失败时临时文件会被删除
+func (fs *FileStorage) writeTemp(path string, reader io.Reader) (string, error) {
 	// 确保目录存在
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, fs.MkdirPermission); err != nil {
 		fs.Logger.Error("创建目录失败: %v", err)
-		return fmt.Errorf("创建目录失败: %v", err)
+		return "", fmt.Errorf("创建目录失败: %v", err)
 	}
 
-	// 创建文件
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.FilePermission)
+	// 创建临时文件
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
 		fs.Logger.Error("创建文件失败: %v", err)
-		return fmt.Errorf("创建文件失败: %v", err)
+		return "", fmt.Errorf("创建文件失败: %v", err)
 	}
-	defer file.Close()
+	tmpPath := file.Name()
+	written := false
+	defer func() {
+		if !written {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
 
 	// 写入数据
-	written, err := io.Copy(file, reader)
-	if err != nil {
+	if _, err := io.Copy(file, reader); err != nil {
+		fs.Logger.Error("写入文件失败: %v", err)
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		fs.Logger.Error("写入文件失败: %v", err)
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := file.Chmod(fs.FilePermission); err != nil {
+		fs.Logger.Warning("设置文件权限失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
 		fs.Logger.Error("写入文件失败: %v", err)
-		return fmt.Errorf("写入文件失败: %v", err)
+		return "", fmt.Errorf("写入文件失败: %v", err)
 	}
+	written = true
+	return tmpPath, nil
+}
 
-	fs.Logger.Info("成功保存 %d 字节数据到: %s", written, path)
+// commitTemp 将 writeTemp 写入的临时文件重命名为目标文件，失败时删除临时文件
+func (fs *FileStorage) commitTemp(tmpPath, path string) error {
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		fs.Logger.Error("重命名文件失败: %v", err)
+		return fmt.Errorf("重命名文件失败: %v", err)
+	}
 	return nil
 }
 
+// countingReader 统计已读取的字节数
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+// Read 实现 io.Reader 接口
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
 // Exists 检查路径是否存在
 func (fs *FileStorage) Exists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
 
+// Load 读取指定路径的文件内容
+func (fs *FileStorage) Load(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return data, nil
+}
+
 // ImageFilenameGenerator 是生成图片文件名的接口
 type ImageFilenameGenerator interface {
 	// GenerateImageFilename 基于图片数据生成文件名
@@ -105,7 +167,8 @@ type ImageFilenameGenerator interface {
 
 // DefaultFilenameGenerator 是默认的文件名生成器
 type DefaultFilenameGenerator struct {
-	Logger Logger // 日志记录器
+	Logger    Logger             // 日志记录器
+	Sanitizer *FilenameSanitizer // 文件名清理器，为 nil 时使用默认配置
 }
 
 // NewDefaultFilenameGenerator 创建一个新的默认文件名生成器
@@ -115,37 +178,28 @@ func NewDefaultFilenameGenerator(logger Logger) *DefaultFilenameGenerator {
 	}
 
 	return &DefaultFilenameGenerator{
-		Logger: logger,
+		Logger:    logger,
+		Sanitizer: NewFilenameSanitizer(),
 	}
 }
 
 // GenerateImageFilename 根据图片数据生成图片文件名
 func (g *DefaultFilenameGenerator) GenerateImageFilename(imageData *ImageData, basePath string) string {
-	// 优先使用标题作为文件名
-	description := imageData.Title
-
-	// 如果标题为空，从版权信息中提取
-	if description == "" {
-		description = strings.Split(imageData.Copyright, "，")[0]
-		description = strings.Split(description, "(")[0]
+	sanitizer := g.Sanitizer
+	if sanitizer == nil {
+		sanitizer = NewFilenameSanitizer()
 	}
 
-	description = strings.TrimSpace(description)
+	// 优先使用标题作为文件名，标题为空时从版权信息中提取
+	description := sanitizer.Sanitize(wallpaperDescription(imageData))
 
-	// 替换特殊字符
-	description = strings.ReplaceAll(description, " ", "_")
-	description = strings.ReplaceAll(description, "/", "-")
-	description = strings.ReplaceAll(description, "\\", "-")
-	description = strings.ReplaceAll(description, ":", "-")
-	description = strings.ReplaceAll(description, "?", "")
-	description = strings.ReplaceAll(description, "*", "")
-	description = strings.ReplaceAll(description, "<", "")
-	description = strings.ReplaceAll(description, ">", "")
-	description = strings.ReplaceAll(description, "|", "-")
-	description = strings.ReplaceAll(description, "\"", "'")
+	// 清理后为空（如仅 ASCII 模式下的中文标题）时使用图片名称
+	if description == "" {
+		description = sanitizer.Sanitize(ParseImageName(imageData))
+	}
 
 	// 生成文件名
-	filename := fmt.Sprintf("%s_%s.jpg", imageData.Startdate, description)
+	filename := fmt.Sprintf("%s_%s.jpg", sanitizer.Sanitize(imageData.Startdate), description)
 
 	g.Logger.Debug("生成图片文件名: %s", filename)
 	return filepath.Join(basePath, filename)
@@ -154,7 +208,7 @@ func (g *DefaultFilenameGenerator) GenerateImageFilename(imageData *ImageData, b
 // GenerateJsonFilename 根据图片数据生成 JSON 文件名
 func (g *DefaultFilenameGenerator) GenerateJsonFilename(imageData *ImageData, basePath string) string {
 	// 使用日期作为文件名
-	filename := fmt.Sprintf("bing_data_%s.json", imageData.Startdate)
+	filename := fmt.Sprintf("bing_data_%s.json", SanitizeFilename(imageData.Startdate))
 
 	g.Logger.Debug("生成 JSON 文件名: %s", filename)
 	return filepath.Join(basePath, filename)
@@ -166,6 +220,15 @@ type BingImageStorage struct {
 	Generator ImageFilenameGenerator // 文件名生成器
 	OutputDir string                 // 输出目录
 	Logger    Logger                 // 日志记录器
+
+	// LatestAlias 为 true 时在输出目录中维护 latest.jpg 和 latest.json 别名
+	LatestAlias bool
+	// MarketAlias 非空时额外维护 today-<market>.jpg 别名
+	MarketAlias string
+	// EmbedMetadata 为 true 时将标题、版权等信息以 XMP 和 IPTC 形式写入 JPEG
+	EmbedMetadata bool
+
+	aliasDates map[string]string // 各类别名当前指向的图片日期
 }
 
 // NewBingImageStorage 创建一个新的 Bing 壁纸存储工具
@@ -184,22 +247,50 @@ func NewBingImageStorage(outputDir string, logger Logger) *BingImageStorage {
 
 // SaveImage 保存图片数据到文件
 func (bis *BingImageStorage) SaveImage(data []byte, imageData *ImageData) (string, error) {
+	filePath, _, _, err := bis.saveImage(data, imageData)
+	return filePath, err
+}
+
+// saveImage 保存图片数据，同时返回实际写入存储的数据
+// 存储支持读取且已保存的图片与新数据完全相同时跳过写入，unchanged 为 true
+func (bis *BingImageStorage) saveImage(data []byte, imageData *ImageData) (filePath string, saved []byte, unchanged bool, err error) {
 	bis.Logger.Info("保存图片数据...")
 
 	// 生成文件路径
-	filePath := bis.Generator.GenerateImageFilename(imageData, bis.OutputDir)
+	filePath = bis.Generator.GenerateImageFilename(imageData, bis.OutputDir)
+
+	// 写入嵌入式元数据
+	data = bis.embedMetadata(data, imageData)
+
+	if loader, ok := bis.Storage.(LoadableStorage); ok && bis.Storage.Exists(filePath) {
+		if existing, err := loader.Load(filePath); err == nil && bytes.Equal(existing, data) {
+			bis.Logger.Info("图片未变化，跳过保存: %s", filePath)
+			bis.updateAliases(imageData, filePath, data, true)
+			return filePath, data, true, nil
+		}
+	}
 
 	// 保存数据
-	err := bis.Storage.Save(data, filePath)
-	if err != nil {
-		return "", err
+	if err := bis.Storage.Save(data, filePath); err != nil {
+		return "", nil, false, err
 	}
 
-	return filePath, nil
+	bis.updateAliases(imageData, filePath, data, true)
+	return filePath, data, false, nil
 }
 
 // SaveImageFromReader 从读取器保存图片数据
 func (bis *BingImageStorage) SaveImageFromReader(reader io.Reader, imageData *ImageData) (string, error) {
+	// 写入嵌入式元数据需要完整的图片数据
+	if bis.EmbedMetadata {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			bis.Logger.Error("读取图片数据失败: %v", err)
+			return "", fmt.Errorf("读取图片数据失败: %v", err)
+		}
+		return bis.SaveImage(data, imageData)
+	}
+
 	bis.Logger.Info("从读取器保存图片数据...")
 
 	// 生成文件路径
@@ -211,10 +302,27 @@ func (bis *BingImageStorage) SaveImageFromReader(reader io.Reader, imageData *Im
 		return "", err
 	}
 
+	bis.updateAliases(imageData, filePath, nil, true)
 	return filePath, nil
 }
 
-// SaveJson 保存 JSON 数据到文件
+// embedMetadata 在启用时将描述信息写入 JPEG，失败时返回原始数据
+func (bis *BingImageStorage) embedMetadata(data []byte, imageData *ImageData) []byte {
+	if !bis.EmbedMetadata {
+		return data
+	}
+
+	embedded, err := EmbedJPEGMetadata(data, NewEmbeddedMetadata(imageData))
+	if err != nil {
+		bis.Logger.Warning("写入图片元数据失败，保存原始图片: %v", err)
+		return data
+	}
+
+	bis.Logger.Debug("已写入 XMP/IPTC 元数据 (%d 字节)", len(embedded)-len(data))
+	return embedded
+}
+
+// SaveJson 保存原始 JSON 数据到文件
 func (bis *BingImageStorage) SaveJson(data []byte, imageData *ImageData) (string, error) {
 	bis.Logger.Info("保存 JSON 数据...")
 
@@ -227,6 +335,7 @@ func (bis *BingImageStorage) SaveJson(data []byte, imageData *ImageData) (string
 		return "", err
 	}
 
+	bis.updateAliases(imageData, filePath, data, false)
 	return filePath, nil
 }
 
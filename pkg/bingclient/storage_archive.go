@@ -0,0 +1,722 @@
+package bingclient
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveFormat 表示归档文件格式
+type ArchiveFormat int
+
+const (
+	// ArchiveZip zip 格式
+	ArchiveZip ArchiveFormat = iota
+	// ArchiveTarGz tar.gz 格式
+	ArchiveTarGz
+)
+
+// ArchiveManifestName 是归档内清单文件的名称
+const ArchiveManifestName = "manifest.json"
+
+// String 返回格式名称
+func (f ArchiveFormat) String() string {
+	switch f {
+	case ArchiveZip:
+		return "zip"
+	case ArchiveTarGz:
+		return "tar.gz"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseArchiveFormat 根据格式名称解析归档格式
+func ParseArchiveFormat(name string) (ArchiveFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "zip":
+		return ArchiveZip, nil
+	case "tar.gz", "tgz":
+		return ArchiveTarGz, nil
+	default:
+		return 0, fmt.Errorf("不支持的归档格式: %s", name)
+	}
+}
+
+// ArchiveFormatFromPath 根据文件扩展名推断归档格式
+func ArchiveFormatFromPath(archivePath string) (ArchiveFormat, error) {
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, nil
+	default:
+		return 0, fmt.Errorf("无法从文件名推断归档格式: %s", archivePath)
+	}
+}
+
+// ArchiveManifestEntry 是清单中单个条目的元数据
+type ArchiveManifestEntry struct {
+	Name    string    `json:"name"`    // 归档内路径
+	Type    string    `json:"type"`    // 条目类型 (image, json, other)
+	Size    int64     `json:"size"`    // 字节数
+	SHA256  string    `json:"sha256"`  // SHA-256 校验和
+	ModTime time.Time `json:"modTime"` // 修改时间
+}
+
+// ArchiveManifest 是归档内的清单文件
+type ArchiveManifest struct {
+	Format  string                 `json:"format"`  // 归档格式
+	Updated time.Time              `json:"updated"` // 最后更新时间
+	Entries []ArchiveManifestEntry `json:"entries"` // 条目列表
+}
+
+// Find 按名称查找条目
+func (m *ArchiveManifest) Find(name string) (*ArchiveManifestEntry, bool) {
+	for i := range m.Entries {
+		if m.Entries[i].Name == name {
+			return &m.Entries[i], true
+		}
+	}
+	return nil, false
+}
+
+// archiveSource 是待写入归档的条目来源
+type archiveSource struct {
+	name    string
+	modTime time.Time
+	open    func() (io.ReadCloser, error)
+}
+
+// DefaultArchiveFlushBytes 是归档待写入条目的默认内存上限，超过后自动写入归档
+const DefaultArchiveFlushBytes = 256 << 20
+
+// ArchiveStorage 是将图片和 JSON 写入 zip 或 tar.gz 归档的存储实现
+// Save 只将条目加入内存中的待写入队列，Flush 或 Close 时一次性重写归档（写入临时文件后原子替换）并更新清单，
+// 避免每保存一个文件就重写整个归档；调用方必须在保存结束后调用 Close，否则队列中的条目会丢失
+type ArchiveStorage struct {
+	ArchivePath    string        // 归档文件路径
+	BaseDir        string        // 基础目录，用于计算条目在归档内的相对路径
+	Format         ArchiveFormat // 归档格式
+	Logger         Logger        // 日志记录器
+	FilePermission os.FileMode   // 归档文件权限
+	FlushBytes     int64         // 待写入条目的内存上限，超过后自动写入归档，为 0 时不自动写入
+
+	mu           sync.Mutex
+	flushMu      sync.Mutex
+	manifest     *ArchiveManifest
+	pending      []archiveSource
+	pendingData  map[string][]byte
+	pendingBytes int64
+}
+
+// NewArchiveStorage 创建一个新的归档存储实例，格式由归档文件扩展名决定
+func NewArchiveStorage(archivePath, baseDir string, logger Logger) (*ArchiveStorage, error) {
+	format, err := ArchiveFormatFromPath(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewArchiveStorageWithFormat(archivePath, baseDir, format, logger), nil
+}
+
+// NewArchiveStorageWithFormat 创建一个指定格式的归档存储实例
+func NewArchiveStorageWithFormat(archivePath, baseDir string, format ArchiveFormat, logger Logger) *ArchiveStorage {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	return &ArchiveStorage{
+		ArchivePath:    archivePath,
+		BaseDir:        baseDir,
+		Format:         format,
+		Logger:         logger,
+		FilePermission: 0644,
+		FlushBytes:     DefaultArchiveFlushBytes,
+	}
+}
+
+// entryName 计算文件路径在归档内的条目名称
+func (as *ArchiveStorage) entryName(filePath string) string {
+	if as.BaseDir != "" {
+		if rel, err := filepath.Rel(as.BaseDir, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(filePath)
+}
+
+// Save 将数据作为条目加入待写入队列，同名条目会被替换
+func (as *ArchiveStorage) Save(data []byte, filePath string) error {
+	name := as.entryName(filePath)
+	as.Logger.Debug("保存 %d 字节数据到归档 %s: %s", len(data), as.ArchivePath, name)
+
+	as.mu.Lock()
+	if as.pendingData == nil {
+		as.pendingData = make(map[string][]byte)
+	}
+	if old, ok := as.pendingData[name]; ok {
+		as.pendingBytes -= int64(len(old))
+		for i, source := range as.pending {
+			if source.name == name {
+				as.pending = append(as.pending[:i], as.pending[i+1:]...)
+				break
+			}
+		}
+	}
+	as.pendingData[name] = data
+	as.pendingBytes += int64(len(data))
+	as.pending = append(as.pending, archiveSource{
+		name:    name,
+		modTime: time.Now(),
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+	})
+	full := as.FlushBytes > 0 && as.pendingBytes >= as.FlushBytes
+	as.mu.Unlock()
+
+	if full {
+		return as.Flush()
+	}
+	return nil
+}
+
+// Flush 将待写入队列中的条目写入归档，写入失败时条目保留在队列中
+func (as *ArchiveStorage) Flush() error {
+	as.flushMu.Lock()
+	defer as.flushMu.Unlock()
+
+	as.mu.Lock()
+	sources, data := as.pending, as.pendingData
+	as.pending, as.pendingData, as.pendingBytes = nil, nil, 0
+	as.mu.Unlock()
+	if len(sources) == 0 {
+		return nil
+	}
+
+	if err := as.write(sources); err != nil {
+		// 放回队列，写入期间重新保存的同名条目以新数据为准
+		as.mu.Lock()
+		if as.pendingData == nil {
+			as.pendingData = make(map[string][]byte)
+		}
+		var restored []archiveSource
+		for _, source := range sources {
+			if _, ok := as.pendingData[source.name]; ok {
+				continue
+			}
+			as.pendingData[source.name] = data[source.name]
+			as.pendingBytes += int64(len(data[source.name]))
+			restored = append(restored, source)
+		}
+		as.pending = append(restored, as.pending...)
+		as.mu.Unlock()
+		return err
+	}
+
+	as.Logger.Info("成功将 %d 个条目写入归档: %s", len(sources), as.ArchivePath)
+	return nil
+}
+
+// Close 写入待写入队列中的条目
+func (as *ArchiveStorage) Close() error {
+	return as.Flush()
+}
+
+// SaveReader 从读取器读取数据并作为条目写入归档
+func (as *ArchiveStorage) SaveReader(reader io.Reader, filePath string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		as.Logger.Error("读取数据失败: %v", err)
+		return fmt.Errorf("读取数据失败: %v", err)
+	}
+	return as.Save(data, filePath)
+}
+
+// Exists 检查归档或待写入队列中是否存在对应条目
+func (as *ArchiveStorage) Exists(filePath string) bool {
+	as.mu.Lock()
+	_, ok := as.pendingData[as.entryName(filePath)]
+	as.mu.Unlock()
+	if ok {
+		return true
+	}
+
+	manifest, err := as.Manifest()
+	if err != nil {
+		return false
+	}
+	_, ok = manifest.Find(as.entryName(filePath))
+	return ok
+}
+
+// Load 读取归档或待写入队列中对应条目的数据
+func (as *ArchiveStorage) Load(filePath string) ([]byte, error) {
+	name := as.entryName(filePath)
+	as.mu.Lock()
+	data, ok := as.pendingData[name]
+	as.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	found := false
+	err := as.walk(func(entryName string, modTime time.Time, reader io.Reader) error {
+		if entryName != name {
+			return nil
+		}
+		var err error
+		data, err = io.ReadAll(reader)
+		found = true
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("归档中不存在条目: %s", name)
+	}
+	return data, nil
+}
+
+// Manifest 返回归档的清单
+func (as *ArchiveStorage) Manifest() (*ArchiveManifest, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.loadManifest()
+}
+
+// AddDirectory 将目录中的所有文件立即写入归档，filter 为 nil 时写入全部文件
+// 返回写入的文件数量
+func (as *ArchiveStorage) AddDirectory(dir string, filter func(relPath string) bool) (int, error) {
+	absArchive, _ := filepath.Abs(as.ArchivePath)
+
+	var sources []archiveSource
+	err := filepath.WalkDir(dir, func(filePath string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		// 跳过归档文件自身
+		if absPath, _ := filepath.Abs(filePath); absPath == absArchive {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ArchiveManifestName || entry.Name() == ManifestLockName || (filter != nil && !filter(rel)) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		sourcePath := filePath
+		sources = append(sources, archiveSource{
+			name:    rel,
+			modTime: info.ModTime(),
+			open: func() (io.ReadCloser, error) {
+				return os.Open(sourcePath)
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		as.Logger.Error("遍历目录失败: %v", err)
+		return 0, fmt.Errorf("遍历目录失败: %v", err)
+	}
+
+	if len(sources) == 0 {
+		as.Logger.Warning("目录中没有可归档的文件: %s", dir)
+		return 0, nil
+	}
+
+	if err := as.write(sources); err != nil {
+		return 0, err
+	}
+
+	as.Logger.Info("已将 %d 个文件写入归档: %s", len(sources), as.ArchivePath)
+	return len(sources), nil
+}
+
+// ExtractTo 将归档中的条目解压到指定目录，并按清单校验 SHA-256
+// 校验失败的条目不会写入；清单中没有记录摘要的条目（如其他工具生成的归档）无法校验，照常写入并记录警告
+// overwrite 为 false 时跳过已存在的文件，返回写入的文件数量
+func (as *ArchiveStorage) ExtractTo(dir string, storage Storage, overwrite bool) (int, error) {
+	manifest, err := as.Manifest()
+	if err != nil {
+		return 0, err
+	}
+
+	var extracted int
+	var failures []string
+	err = as.walk(func(name string, modTime time.Time, reader io.Reader) error {
+		if name == ArchiveManifestName {
+			return nil
+		}
+
+		target, err := archiveTargetPath(dir, name)
+		if err != nil {
+			as.Logger.Warning("跳过不安全的条目: %v", err)
+			failures = append(failures, name)
+			return nil
+		}
+
+		if !overwrite && storage.Exists(target) {
+			as.Logger.Info("文件已存在，跳过: %s", target)
+			return nil
+		}
+
+		// 先读入内存并校验，校验失败的条目不会写入目标目录
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("读取条目 %s 失败: %v", name, err)
+		}
+		if entry, ok := manifest.Find(name); ok && entry.SHA256 != "" {
+			sum := sha256.Sum256(data)
+			if actual := hex.EncodeToString(sum[:]); actual != entry.SHA256 {
+				as.Logger.Error("条目校验失败: %s (期望 %s，实际 %s)", name, entry.SHA256, actual)
+				failures = append(failures, name)
+				return nil
+			}
+		} else {
+			as.Logger.Warning("清单中没有条目的摘要，未校验: %s", name)
+		}
+
+		if err := storage.Save(data, target); err != nil {
+			as.Logger.Error("写入条目 %s 失败: %v", name, err)
+			failures = append(failures, name)
+			return nil
+		}
+
+		extracted++
+		return nil
+	})
+	if err != nil {
+		return extracted, err
+	}
+
+	if len(failures) > 0 {
+		return extracted, fmt.Errorf("%d 个条目导入失败: %s", len(failures), strings.Join(failures, ", "))
+	}
+	return extracted, nil
+}
+
+// archiveTargetPath 计算条目解压后的路径，拒绝逃逸出目标目录的条目
+func archiveTargetPath(dir, name string) (string, error) {
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("条目路径无效: %s", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(cleaned)), nil
+}
+
+// archiveEntryType 根据扩展名判断条目类型
+func archiveEntryType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return "image"
+	case ".json":
+		return "json"
+	default:
+		return "other"
+	}
+}
+
+// loadManifest 从归档中读取清单，调用方需持有锁
+func (as *ArchiveStorage) loadManifest() (*ArchiveManifest, error) {
+	if as.manifest != nil {
+		return as.manifest, nil
+	}
+
+	manifest := &ArchiveManifest{Format: as.Format.String()}
+	if _, err := os.Stat(as.ArchivePath); os.IsNotExist(err) {
+		as.manifest = manifest
+		return manifest, nil
+	}
+
+	err := as.walk(func(name string, modTime time.Time, reader io.Reader) error {
+		if name != ArchiveManifestName {
+			return nil
+		}
+		return json.NewDecoder(reader).Decode(manifest)
+	})
+	if err != nil {
+		as.Logger.Error("读取归档清单失败: %v", err)
+		return nil, fmt.Errorf("读取归档清单失败: %v", err)
+	}
+
+	as.manifest = manifest
+	return manifest, nil
+}
+
+// walk 依次遍历归档中的每个条目
+func (as *ArchiveStorage) walk(fn func(name string, modTime time.Time, reader io.Reader) error) error {
+	file, err := os.Open(as.ArchivePath)
+	if err != nil {
+		return fmt.Errorf("打开归档失败: %v", err)
+	}
+	defer file.Close()
+
+	switch as.Format {
+	case ArchiveZip:
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("读取归档信息失败: %v", err)
+		}
+		zr, err := zip.NewReader(file, info.Size())
+		if err != nil {
+			return fmt.Errorf("读取 zip 归档失败: %v", err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("读取条目 %s 失败: %v", f.Name, err)
+			}
+			err = fn(f.Name, f.Modified, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	case ArchiveTarGz:
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("读取 gzip 数据失败: %v", err)
+		}
+		defer gz.Close()
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("读取 tar 归档失败: %v", err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := fn(header.Name, header.ModTime, tr); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("不支持的归档格式: %s", as.Format)
+	}
+	return nil
+}
+
+// write 重写归档：保留未被替换的旧条目，写入新条目和更新后的清单
+func (as *ArchiveStorage) write(sources []archiveSource) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	manifest, err := as.loadManifest()
+	if err != nil {
+		return err
+	}
+
+	replaced := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		replaced[source.name] = true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(as.ArchivePath), 0755); err != nil {
+		as.Logger.Error("创建目录失败: %v", err)
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(as.ArchivePath), ".archive-*.tmp")
+	if err != nil {
+		as.Logger.Error("创建临时文件失败: %v", err)
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	writer, err := newArchiveWriter(as.Format, tmp)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	// 复制旧条目
+	if _, err := os.Stat(as.ArchivePath); err == nil {
+		err = as.walk(func(name string, modTime time.Time, reader io.Reader) error {
+			if name == ArchiveManifestName || replaced[name] {
+				return nil
+			}
+			_, err := writer.add(name, modTime, reader)
+			return err
+		})
+		if err != nil {
+			writer.close()
+			tmp.Close()
+			as.Logger.Error("复制归档条目失败: %v", err)
+			return fmt.Errorf("复制归档条目失败: %v", err)
+		}
+	}
+
+	// 写入新条目
+	entries := make([]ArchiveManifestEntry, 0, len(manifest.Entries)+len(sources))
+	for _, entry := range manifest.Entries {
+		if !replaced[entry.Name] {
+			entries = append(entries, entry)
+		}
+	}
+	for _, source := range sources {
+		reader, err := source.open()
+		if err != nil {
+			writer.close()
+			tmp.Close()
+			as.Logger.Error("读取 %s 失败: %v", source.name, err)
+			return fmt.Errorf("读取 %s 失败: %v", source.name, err)
+		}
+		hasher := sha256.New()
+		size, err := writer.add(source.name, source.modTime, io.TeeReader(reader, hasher))
+		reader.Close()
+		if err != nil {
+			writer.close()
+			tmp.Close()
+			as.Logger.Error("写入归档条目失败: %v", err)
+			return fmt.Errorf("写入归档条目失败: %v", err)
+		}
+		entries = append(entries, ArchiveManifestEntry{
+			Name:    source.name,
+			Type:    archiveEntryType(source.name),
+			Size:    size,
+			SHA256:  hex.EncodeToString(hasher.Sum(nil)),
+			ModTime: source.modTime,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	// 写入清单
+	updated := &ArchiveManifest{
+		Format:  as.Format.String(),
+		Updated: time.Now(),
+		Entries: entries,
+	}
+	manifestData, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		writer.close()
+		tmp.Close()
+		return fmt.Errorf("生成归档清单失败: %v", err)
+	}
+	if _, err := writer.add(ArchiveManifestName, updated.Updated, bytes.NewReader(manifestData)); err != nil {
+		writer.close()
+		tmp.Close()
+		return fmt.Errorf("写入归档清单失败: %v", err)
+	}
+
+	if err := writer.close(); err != nil {
+		tmp.Close()
+		as.Logger.Error("完成归档写入失败: %v", err)
+		return fmt.Errorf("完成归档写入失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	if err := os.Chmod(tmpPath, as.FilePermission); err != nil {
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, as.ArchivePath); err != nil {
+		as.Logger.Error("替换归档文件失败: %v", err)
+		return fmt.Errorf("替换归档文件失败: %v", err)
+	}
+
+	as.manifest = updated
+	return nil
+}
+
+// archiveWriter 封装 zip 与 tar.gz 的写入差异
+type archiveWriter struct {
+	zw *zip.Writer
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+// newArchiveWriter 创建指定格式的归档写入器
+func newArchiveWriter(format ArchiveFormat, w io.Writer) (*archiveWriter, error) {
+	switch format {
+	case ArchiveZip:
+		return &archiveWriter{zw: zip.NewWriter(w)}, nil
+	case ArchiveTarGz:
+		gz := gzip.NewWriter(w)
+		return &archiveWriter{gz: gz, tw: tar.NewWriter(gz)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的归档格式: %s", format)
+	}
+}
+
+// add 写入一个条目并返回写入的字节数
+func (aw *archiveWriter) add(name string, modTime time.Time, reader io.Reader) (int64, error) {
+	if aw.zw != nil {
+		// JPEG 已经是压缩格式，直接存储即可
+		method := zip.Deflate
+		if archiveEntryType(name) == "image" {
+			method = zip.Store
+		}
+		w, err := aw.zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modTime})
+		if err != nil {
+			return 0, err
+		}
+		return io.Copy(w, reader)
+	}
+
+	// tar 头部需要提前知道大小
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return 0, err
+	}
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := aw.tw.WriteHeader(header); err != nil {
+		return 0, err
+	}
+	n, err := aw.tw.Write(data)
+	return int64(n), err
+}
+
+// close 完成归档写入
+func (aw *archiveWriter) close() error {
+	if aw.zw != nil {
+		return aw.zw.Close()
+	}
+	if err := aw.tw.Close(); err != nil {
+		return err
+	}
+	return aw.gz.Close()
+}
@@ -0,0 +1,133 @@
+package bingclient
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// LoadableStorage 是可读取已保存数据的存储接口
+// 并非所有存储都支持读取，调用方应通过类型断言判断
+type LoadableStorage interface {
+	Storage
+	// Load 读取指定路径的数据
+	Load(path string) ([]byte, error)
+}
+
+// MemoryStorage 是一个内存存储实现，主要用于测试
+type MemoryStorage struct {
+	Logger Logger // 日志记录器
+
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryStorage 创建一个新的内存存储实例
+func NewMemoryStorage(logger Logger) *MemoryStorage {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	return &MemoryStorage{
+		Logger: logger,
+		files:  make(map[string][]byte),
+	}
+}
+
+// Save 将数据保存到内存中
+func (ms *MemoryStorage) Save(data []byte, path string) error {
+	ms.Logger.Debug("保存 %d 字节数据到内存: %s", len(data), path)
+
+	// 复制一份数据，避免调用方修改切片影响已保存内容
+	copied := make([]byte, len(data))
+	copy(copied, data)
+
+	ms.mu.Lock()
+	ms.files[path] = copied
+	ms.mu.Unlock()
+
+	return nil
+}
+
+// SaveReader 从读取器保存数据到内存中
+func (ms *MemoryStorage) SaveReader(reader io.Reader, path string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		ms.Logger.Error("读取数据失败: %v", err)
+		return fmt.Errorf("读取数据失败: %v", err)
+	}
+
+	return ms.Save(data, path)
+}
+
+// Exists 检查路径是否存在
+func (ms *MemoryStorage) Exists(path string) bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	_, ok := ms.files[path]
+	return ok
+}
+
+// Load 读取指定路径的数据
+func (ms *MemoryStorage) Load(path string) ([]byte, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	data, ok := ms.files[path]
+	if !ok {
+		return nil, fmt.Errorf("文件不存在: %s", path)
+	}
+
+	copied := make([]byte, len(data))
+	copy(copied, data)
+	return copied, nil
+}
+
+// Paths 返回已保存的所有路径（按字典序排列）
+func (ms *MemoryStorage) Paths() []string {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	paths := make([]string, 0, len(ms.files))
+	for path := range ms.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Len 返回已保存的文件数量
+func (ms *MemoryStorage) Len() int {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return len(ms.files)
+}
+
+// Size 返回指定路径的数据大小，文件不存在时返回 -1
+func (ms *MemoryStorage) Size(path string) int {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	data, ok := ms.files[path]
+	if !ok {
+		return -1
+	}
+	return len(data)
+}
+
+// Delete 删除指定路径的数据
+func (ms *MemoryStorage) Delete(path string) {
+	ms.mu.Lock()
+	delete(ms.files, path)
+	ms.mu.Unlock()
+}
+
+// Reset 清空所有已保存的数据
+func (ms *MemoryStorage) Reset() {
+	ms.mu.Lock()
+	ms.files = make(map[string][]byte)
+	ms.mu.Unlock()
+}
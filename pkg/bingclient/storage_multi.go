@@ -0,0 +1,200 @@
+package bingclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MultiStorageMode 定义多路存储的成功判定方式
+type MultiStorageMode int
+
+const (
+	// MultiStorageAll 所有后端都保存成功才算成功
+	MultiStorageAll MultiStorageMode = iota
+	// MultiStorageAny 任意一个后端保存成功即算成功
+	MultiStorageAny
+)
+
+// BackendError 记录单个存储后端的错误
+type BackendError struct {
+	Backend string // 后端名称
+	Path    string // 保存路径
+	Err     error  // 错误信息
+}
+
+// Error 实现 error 接口
+func (e *BackendError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Backend, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *BackendError) Unwrap() error {
+	return e.Err
+}
+
+// MultiStorageError 汇总多个后端的保存错误
+type MultiStorageError struct {
+	Errors []*BackendError
+}
+
+// Error 实现 error 接口
+func (e *MultiStorageError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d 个存储后端保存失败: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// BackendErrorReporter 是可报告各后端保存错误的存储接口
+// Downloader 会通过它将部分失败的信息记录到 DownloadResult 中
+type BackendErrorReporter interface {
+	// TakeBackendErrors 返回自上次调用以来累积的后端错误并清空记录
+	TakeBackendErrors() []*BackendError
+}
+
+// storageBackend 是带名称的存储后端
+type storageBackend struct {
+	name    string
+	storage Storage
+}
+
+// MultiStorage 是将数据同时写入多个存储后端的存储实现
+type MultiStorage struct {
+	Mode   MultiStorageMode // 成功判定方式
+	Logger Logger           // 日志记录器
+
+	backends []storageBackend
+	mu       sync.Mutex
+	errors   []*BackendError
+}
+
+// NewMultiStorage 创建一个新的多路存储实例
+func NewMultiStorage(mode MultiStorageMode, logger Logger) *MultiStorage {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+
+	return &MultiStorage{
+		Mode:   mode,
+		Logger: logger,
+	}
+}
+
+// AddBackend 添加一个存储后端，name 用于错误报告
+func (ms *MultiStorage) AddBackend(name string, storage Storage) *MultiStorage {
+	if name == "" {
+		name = fmt.Sprintf("backend-%d", len(ms.backends))
+	}
+	ms.backends = append(ms.backends, storageBackend{name: name, storage: storage})
+	return ms
+}
+
+// Backends 返回所有后端的名称
+func (ms *MultiStorage) Backends() []string {
+	names := make([]string, 0, len(ms.backends))
+	for _, backend := range ms.backends {
+		names = append(names, backend.name)
+	}
+	return names
+}
+
+// Save 将数据保存到所有存储后端
+func (ms *MultiStorage) Save(data []byte, path string) error {
+	if len(ms.backends) == 0 {
+		return fmt.Errorf("未配置任何存储后端")
+	}
+
+	ms.Logger.Debug("保存 %d 字节数据到 %d 个存储后端: %s", len(data), len(ms.backends), path)
+
+	var backendErrors []*BackendError
+	for _, backend := range ms.backends {
+		if err := backend.storage.Save(data, path); err != nil {
+			ms.Logger.Warning("存储后端 %s 保存失败: %v", backend.name, err)
+			backendErrors = append(backendErrors, &BackendError{
+				Backend: backend.name,
+				Path:    path,
+				Err:     err,
+			})
+		}
+	}
+
+	return ms.finish(backendErrors)
+}
+
+// SaveReader 从读取器保存数据到所有存储后端
+// 读取器只能读取一次，因此会先将数据完整读入内存
+func (ms *MultiStorage) SaveReader(reader io.Reader, path string) error {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, reader); err != nil {
+		ms.Logger.Error("读取数据失败: %v", err)
+		return fmt.Errorf("读取数据失败: %v", err)
+	}
+
+	return ms.Save(buf.Bytes(), path)
+}
+
+// Exists 检查路径是否存在
+// MultiStorageAll 模式下要求所有后端都存在，MultiStorageAny 模式下任意后端存在即可
+func (ms *MultiStorage) Exists(path string) bool {
+	if len(ms.backends) == 0 {
+		return false
+	}
+
+	for _, backend := range ms.backends {
+		exists := backend.storage.Exists(path)
+		if ms.Mode == MultiStorageAny && exists {
+			return true
+		}
+		if ms.Mode == MultiStorageAll && !exists {
+			return false
+		}
+	}
+
+	return ms.Mode == MultiStorageAll
+}
+
+// Load 从第一个支持读取且存在该路径的后端读取数据
+func (ms *MultiStorage) Load(path string) ([]byte, error) {
+	for _, backend := range ms.backends {
+		loader, ok := backend.storage.(LoadableStorage)
+		if !ok || !backend.storage.Exists(path) {
+			continue
+		}
+		return loader.Load(path)
+	}
+	return nil, fmt.Errorf("没有可读取 %s 的存储后端", path)
+}
+
+// TakeBackendErrors 返回自上次调用以来累积的后端错误并清空记录
+func (ms *MultiStorage) TakeBackendErrors() []*BackendError {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	collected := ms.errors
+	ms.errors = nil
+	return collected
+}
+
+// finish 记录后端错误并根据模式决定是否返回错误
+func (ms *MultiStorage) finish(backendErrors []*BackendError) error {
+	if len(backendErrors) == 0 {
+		return nil
+	}
+
+	ms.mu.Lock()
+	ms.errors = append(ms.errors, backendErrors...)
+	ms.mu.Unlock()
+
+	failed := &MultiStorageError{Errors: backendErrors}
+	if ms.Mode == MultiStorageAny && len(backendErrors) < len(ms.backends) {
+		ms.Logger.Warning("部分存储后端保存失败，已有 %d/%d 个后端成功", len(ms.backends)-len(backendErrors), len(ms.backends))
+		return nil
+	}
+
+	ms.Logger.Error("%v", failed)
+	return failed
+}
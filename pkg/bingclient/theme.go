@@ -0,0 +1,221 @@
+package bingclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// ThemeFormat 表示配色方案的导出格式
+type ThemeFormat int
+
+const (
+	// ThemeXresources X 资源文件 (~/.Xresources)
+	ThemeXresources ThemeFormat = iota
+	// ThemeKitty kitty 终端配置 (kitty.conf)
+	ThemeKitty
+	// ThemeAlacritty alacritty 终端配置 (TOML)
+	ThemeAlacritty
+	// ThemeJSON JSON 格式，便于编辑器插件等读取
+	ThemeJSON
+)
+
+// String 返回格式名称
+func (f ThemeFormat) String() string {
+	switch f {
+	case ThemeXresources:
+		return "xresources"
+	case ThemeKitty:
+		return "kitty"
+	case ThemeAlacritty:
+		return "alacritty"
+	case ThemeJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseThemeFormat 根据名称解析配色方案格式
+func ParseThemeFormat(name string) (ThemeFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "xresources", "x":
+		return ThemeXresources, nil
+	case "kitty":
+		return ThemeKitty, nil
+	case "alacritty", "toml":
+		return ThemeAlacritty, nil
+	case "json":
+		return ThemeJSON, nil
+	default:
+		return 0, fmt.Errorf("不支持的配色方案格式: %s (可选 xresources, kitty, alacritty, json)", name)
+	}
+}
+
+// ANSI 颜色名称，依次对应 color0-color7
+var ansiColorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ANSI 彩色的标准色相，依次对应 red、green、yellow、blue、magenta、cyan
+var ansiHues = []float64{0, 120, 55, 220, 300, 185}
+
+// Theme 是根据调色板生成的终端配色方案
+type Theme struct {
+	Dark                bool       `json:"dark"`                // 是否为深色方案
+	Background          string     `json:"background"`          // 背景色
+	Foreground          string     `json:"foreground"`          // 前景色
+	Cursor              string     `json:"cursor"`              // 光标颜色
+	SelectionBackground string     `json:"selectionBackground"` // 选中背景色
+	SelectionForeground string     `json:"selectionForeground"` // 选中前景色
+	Colors              [16]string `json:"colors"`              // ANSI color0-color15
+	Palette             []string   `json:"palette,omitempty"`   // 原始调色板
+}
+
+// NewTheme 根据调色板生成配色方案
+// 背景和前景取主色的色相，ANSI 彩色尽量采用调色板中色相相近的颜色，强调色用于光标和选中区域
+func NewTheme(palette *Palette, dark bool) *Theme {
+	dominantHue, dominantSat, _ := rgbToHSL(palette.Dominant())
+	accentHue, accentSat, _ := rgbToHSL(palette.Accent())
+
+	theme := &Theme{
+		Dark:    dark,
+		Palette: palette.Hex(),
+	}
+
+	// 彩色的饱和度跟随图片整体的鲜艳程度，但保持在易于辨认的范围内
+	var saturation float64
+	for _, c := range palette.Colors {
+		_, s, _ := rgbToHSL(c.Color)
+		saturation += s * c.Weight
+	}
+	saturation = math.Max(0.45, math.Min(0.75, saturation+0.25))
+
+	var normalL, brightL float64
+	if dark {
+		theme.Background = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.35), 0.10))
+		theme.Foreground = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.15), 0.88))
+		theme.Cursor = colorHex(hslToRGB(accentHue, math.Max(accentSat, 0.5), 0.65))
+		theme.SelectionBackground = colorHex(hslToRGB(accentHue, math.Max(accentSat, 0.3), 0.28))
+		theme.Colors[0] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.25), 0.18))
+		theme.Colors[8] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.20), 0.38))
+		theme.Colors[7] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.12), 0.78))
+		theme.Colors[15] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.10), 0.95))
+		normalL, brightL = 0.60, 0.72
+	} else {
+		theme.Background = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.25), 0.95))
+		theme.Foreground = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.30), 0.18))
+		theme.Cursor = colorHex(hslToRGB(accentHue, math.Max(accentSat, 0.5), 0.40))
+		theme.SelectionBackground = colorHex(hslToRGB(accentHue, math.Max(accentSat, 0.3), 0.82))
+		theme.Colors[0] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.25), 0.20))
+		theme.Colors[8] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.20), 0.45))
+		theme.Colors[7] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.12), 0.75))
+		theme.Colors[15] = colorHex(hslToRGB(dominantHue, math.Min(dominantSat, 0.10), 0.90))
+		normalL, brightL = 0.40, 0.32
+	}
+	theme.SelectionForeground = theme.Foreground
+
+	for i, hue := range ansiHues {
+		hue = harmonizeHue(palette, hue)
+		theme.Colors[i+1] = colorHex(hslToRGB(hue, saturation, normalL))
+		theme.Colors[i+9] = colorHex(hslToRGB(hue, saturation+0.1, brightL))
+	}
+	return theme
+}
+
+// harmonizeHue 在调色板中寻找与标准色相相近（30 度以内）且足够鲜艳的颜色，将色相向其偏移一半
+// 只偏移一半可以让相邻的 ANSI 颜色（如红和黄）即使靠近同一种颜色也仍然可以区分
+func harmonizeHue(palette *Palette, hue float64) float64 {
+	shift := 0.0
+	for _, c := range palette.Colors {
+		h, s, l := rgbToHSL(c.Color)
+		if s < 0.25 || l < 0.1 || l > 0.9 {
+			continue
+		}
+		// 带符号的最短角度差 (-180, 180]
+		delta := math.Mod(h-hue+540, 360) - 180
+		if math.Abs(delta) < 30 && (shift == 0 || math.Abs(delta) < math.Abs(shift)) {
+			shift = delta
+		}
+	}
+	return math.Mod(hue+shift/2+360, 360)
+}
+
+// Export 按指定格式导出配色方案
+func (t *Theme) Export(format ThemeFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case ThemeXresources:
+		buf.WriteString("! Bing 壁纸配色方案\n")
+		fmt.Fprintf(&buf, "*.background: %s\n", t.Background)
+		fmt.Fprintf(&buf, "*.foreground: %s\n", t.Foreground)
+		fmt.Fprintf(&buf, "*.cursorColor: %s\n", t.Cursor)
+		for i, c := range t.Colors {
+			fmt.Fprintf(&buf, "*.color%d: %s\n", i, c)
+		}
+	case ThemeKitty:
+		buf.WriteString("# Bing 壁纸配色方案\n")
+		fmt.Fprintf(&buf, "background %s\n", t.Background)
+		fmt.Fprintf(&buf, "foreground %s\n", t.Foreground)
+		fmt.Fprintf(&buf, "cursor %s\n", t.Cursor)
+		fmt.Fprintf(&buf, "selection_background %s\n", t.SelectionBackground)
+		fmt.Fprintf(&buf, "selection_foreground %s\n", t.SelectionForeground)
+		for i, c := range t.Colors {
+			fmt.Fprintf(&buf, "color%d %s\n", i, c)
+		}
+	case ThemeAlacritty:
+		buf.WriteString("# Bing 壁纸配色方案\n")
+		buf.WriteString("[colors.primary]\n")
+		fmt.Fprintf(&buf, "background = %q\n", t.Background)
+		fmt.Fprintf(&buf, "foreground = %q\n", t.Foreground)
+		buf.WriteString("\n[colors.cursor]\n")
+		fmt.Fprintf(&buf, "cursor = %q\n", t.Cursor)
+		fmt.Fprintf(&buf, "text = %q\n", t.Background)
+		buf.WriteString("\n[colors.selection]\n")
+		fmt.Fprintf(&buf, "background = %q\n", t.SelectionBackground)
+		fmt.Fprintf(&buf, "text = %q\n", t.SelectionForeground)
+		for _, section := range []struct {
+			name   string
+			offset int
+		}{{"normal", 0}, {"bright", 8}} {
+			fmt.Fprintf(&buf, "\n[colors.%s]\n", section.name)
+			for i, name := range ansiColorNames {
+				fmt.Fprintf(&buf, "%s = %q\n", name, t.Colors[section.offset+i])
+			}
+		}
+	case ThemeJSON:
+		data, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("序列化配色方案失败: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	default:
+		return nil, fmt.Errorf("不支持的配色方案格式: %s", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// PaletteFromHex 根据 #rrggbb 颜色列表重建调色板，用于从元数据生成配色方案
+// 列表按占比从高到低排列，占比按顺序递减估算
+func PaletteFromHex(colors []string) (*Palette, error) {
+	palette := &Palette{}
+	var total float64
+	for i, value := range colors {
+		c, err := ParseHexColor(value)
+		if err != nil {
+			return nil, err
+		}
+		weight := 1 / float64(i+1)
+		total += weight
+		palette.Colors = append(palette.Colors, PaletteColor{Color: c, Weight: weight})
+	}
+	for i := range palette.Colors {
+		palette.Colors[i].Weight /= total
+	}
+	if len(palette.Colors) == 0 {
+		palette.Colors = []PaletteColor{{Color: color.RGBA{A: 255}, Weight: 1}}
+	}
+	return palette, nil
+}
@@ -0,0 +1,93 @@
+package bingclient
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// ThumbnailDir 是缩略图所在的子目录（相对原图所在目录）
+const ThumbnailDir = "thumbs"
+
+// DefaultThumbnailWidths 是默认生成的缩略图宽度
+var DefaultThumbnailWidths = []int{320, 800}
+
+// ThumbnailProcessor 按指定宽度生成等比例缩略图
+type ThumbnailProcessor struct {
+	Widths []int // 缩略图宽度（像素），不会放大原图
+}
+
+// NewThumbnailProcessor 创建一个缩略图处理器，未指定宽度时使用默认宽度
+func NewThumbnailProcessor(widths ...int) *ThumbnailProcessor {
+	if len(widths) == 0 {
+		widths = DefaultThumbnailWidths
+	}
+	return &ThumbnailProcessor{
+		Widths: append([]int(nil), widths...),
+	}
+}
+
+// ParseThumbnailWidths 解析以逗号分隔的缩略图宽度，如 "320,800"
+func ParseThumbnailWidths(value string) ([]int, error) {
+	var widths []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "w"))
+		if part == "" {
+			continue
+		}
+		width, err := strconv.Atoi(part)
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("无效的缩略图宽度: %s", part)
+		}
+		if !seen[width] {
+			seen[width] = true
+			widths = append(widths, width)
+		}
+	}
+	sort.Ints(widths)
+	return widths, nil
+}
+
+// ThumbnailPath 返回指定宽度缩略图的保存路径，如 thumbs/20261018_布莱德湖_320w.jpg
+func ThumbnailPath(imagePath string, width int) string {
+	return variantPath(imagePath, ThumbnailDir, fmt.Sprintf("%dw", width))
+}
+
+// Process 生成全部宽度的缩略图
+func (t *ThumbnailProcessor) Process(src image.Image, imageData *ImageData, imagePath string) ([]*ImageVariant, error) {
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("图片尺寸无效: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	variants := make([]*ImageVariant, 0, len(t.Widths))
+	for _, width := range t.Widths {
+		if width <= 0 || width >= bounds.Dx() {
+			continue
+		}
+		height := (bounds.Dy()*width + bounds.Dx()/2) / bounds.Dx()
+		if height < 1 {
+			height = 1
+		}
+
+		variants = append(variants, &ImageVariant{
+			Kind:  VariantThumbnail,
+			Name:  fmt.Sprintf("%dw", width),
+			Path:  ThumbnailPath(imagePath, width),
+			Image: resizeImage(src, width, height),
+		})
+	}
+	return variants, nil
+}
+
+// resizeImage 使用 Catmull-Rom 插值将图片缩放到指定尺寸
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
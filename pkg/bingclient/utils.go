@@ -65,34 +65,25 @@ func GetTodayDateString() string {
 }
 
 // 检查图片是否是今天的
+// 按图片所属市场的切换时刻判断，而不是本机的日期
 func IsImageFromToday(imageData *ImageData) bool {
-	today := GetTodayDateString()
-	return imageData.Startdate == today
+	return IsImageCurrent(imageData, time.Now())
 }
 
 // 提取壁纸描述（用于文件名）
 func ExtractWallpaperDescription(imageData *ImageData) string {
+	return SanitizeFilename(wallpaperDescription(imageData))
+}
+
+// wallpaperDescription 返回未经清理的壁纸描述
+func wallpaperDescription(imageData *ImageData) string {
 	// 优先使用标题
 	description := imageData.Title
 
-	// 如果标题为空，使用版权信息
+	// 如果标题为空，使用版权信息中的画面描述
 	if description == "" {
-		description = strings.Split(imageData.Copyright, "，")[0]
-		description = strings.Split(description, "(")[0]
+		description = ParseCopyright(imageData.Copyright).Description
 	}
 
-	// 清理和格式化描述
-	description = strings.TrimSpace(description)
-	description = strings.ReplaceAll(description, " ", "_")
-	description = strings.ReplaceAll(description, "/", "-")
-	description = strings.ReplaceAll(description, "\\", "-")
-	description = strings.ReplaceAll(description, ":", "-")
-	description = strings.ReplaceAll(description, "?", "")
-	description = strings.ReplaceAll(description, "*", "")
-	description = strings.ReplaceAll(description, "<", "")
-	description = strings.ReplaceAll(description, ">", "")
-	description = strings.ReplaceAll(description, "|", "-")
-	description = strings.ReplaceAll(description, "\"", "'")
-
-	return description
+	return strings.TrimSpace(description)
 }
@@ -0,0 +1,349 @@
+package bingclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Webhook 的默认参数
+const (
+	DefaultWebhookTimeout    = 10 * time.Second
+	DefaultWebhookRetries    = 3
+	DefaultWebhookRetryDelay = 2 * time.Second
+	// DefaultWebhookMaxRetryDelay 是单次重试前的最长等待时间
+	DefaultWebhookMaxRetryDelay = 10 * time.Second
+	// DefaultSignatureHeader 是 HMAC 签名所在的请求头，值为 sha256=<十六进制摘要>
+	DefaultSignatureHeader = "X-Bing-Signature"
+)
+
+// WebhookFormat 表示 Webhook 请求体的格式
+type WebhookFormat int
+
+const (
+	// WebhookGeneric 通用格式，请求体为 WallpaperEvent 的 JSON
+	WebhookGeneric WebhookFormat = iota
+	// WebhookSlack Slack Incoming Webhook 格式
+	WebhookSlack
+	// WebhookDiscord Discord Webhook 格式
+	WebhookDiscord
+	// WebhookMatrix Matrix m.room.message 消息内容格式
+	WebhookMatrix
+)
+
+// String 返回格式名称
+func (f WebhookFormat) String() string {
+	switch f {
+	case WebhookGeneric:
+		return "generic"
+	case WebhookSlack:
+		return "slack"
+	case WebhookDiscord:
+		return "discord"
+	case WebhookMatrix:
+		return "matrix"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseWebhookFormat 根据名称解析 Webhook 格式
+func ParseWebhookFormat(name string) (WebhookFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "generic", "json", "":
+		return WebhookGeneric, nil
+	case "slack":
+		return WebhookSlack, nil
+	case "discord":
+		return WebhookDiscord, nil
+	case "matrix":
+		return WebhookMatrix, nil
+	default:
+		return 0, fmt.Errorf("不支持的 Webhook 格式: %s (可选 generic, slack, discord, matrix)", name)
+	}
+}
+
+// ParseWebhookTemplate 解析 JSON 请求体模板
+// 模板使用 text/template 语法，数据为 WallpaperEvent，json 函数将值编码为 JSON，
+// 如 {"text": {{json .Metadata.Title}}, "image": {{json .Metadata.URL}}}
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Webhook 模板失败: %v", err)
+	}
+	return tmpl, nil
+}
+
+// WebhookNotifier 通过 HTTP POST 将新壁纸事件发送到 Webhook
+// 网络错误、429 和 5xx 响应按指数退避重试，其他 4xx 响应不重试
+//
+// 作为钩子时通知是同步发送的，下载流程会等待通知完成。每次重试前的等待不超过 MaxRetryDelay，
+// 服务端通过 Retry-After 要求更长的等待时不再重试，因此单个地址最多阻塞
+// (Retries+1)×Client.Timeout + Retries×MaxRetryDelay，默认约 70 秒
+type WebhookNotifier struct {
+	URL        string             // Webhook 地址
+	Format     WebhookFormat      // 请求体格式，设置了 Template 时忽略
+	Template   *template.Template // 自定义 JSON 请求体模板，为 nil 时使用 Format 的默认请求体
+	Headers    map[string]string  // 附加的请求头，如 Authorization
+	Secret     string             // HMAC-SHA256 签名密钥，为空时不签名
+	Retries    int                // 失败后的最大重试次数
+	RetryDelay time.Duration      // 首次重试前的等待时间，之后按指数退避
+	Client     *http.Client       // HTTP 客户端
+	Logger     Logger             // 日志记录器
+
+	// SignatureHeader 是签名所在的请求头，为空时使用 DefaultSignatureHeader
+	SignatureHeader string
+	// MaxRetryDelay 是单次重试前的最长等待时间，为 0 时使用 DefaultWebhookMaxRetryDelay
+	MaxRetryDelay time.Duration
+}
+
+// NewWebhookNotifier 创建一个 Webhook 通知
+func NewWebhookNotifier(url string, format WebhookFormat, logger Logger) *WebhookNotifier {
+	if logger == nil {
+		logger = &NullLogger{}
+	}
+	return &WebhookNotifier{
+		URL:           url,
+		Format:        format,
+		Retries:       DefaultWebhookRetries,
+		RetryDelay:    DefaultWebhookRetryDelay,
+		MaxRetryDelay: DefaultWebhookMaxRetryDelay,
+		Client:        &http.Client{Timeout: DefaultWebhookTimeout},
+		Logger:        logger,
+	}
+}
+
+// OnNewWallpaper 实现 DownloadHook，发送新壁纸通知
+func (n *WebhookNotifier) OnNewWallpaper(result *DownloadResult) error {
+	return n.Notify(NewWallpaperEvent(result))
+}
+
+// Notify 发送事件，全部重试失败后返回最后一次的错误
+func (n *WebhookNotifier) Notify(event *WallpaperEvent) error {
+	body, err := n.Body(event)
+	if err != nil {
+		return err
+	}
+
+	maxDelay := n.MaxRetryDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultWebhookMaxRetryDelay
+	}
+	backoff := NewBackoff(n.RetryDelay, maxDelay)
+	for {
+		retryAfter, err := n.post(body)
+		if err == nil {
+			n.Logger.Info("已发送 Webhook 通知: %s", redactURL(n.URL))
+			return nil
+		}
+		if retryAfter < 0 || backoff.Attempts() >= n.Retries {
+			return fmt.Errorf("发送 Webhook 通知失败: %v", err)
+		}
+		if retryAfter > maxDelay {
+			return fmt.Errorf("发送 Webhook 通知失败: %v (服务器要求 %v 后重试，超过最长等待时间 %v)", err, retryAfter, maxDelay)
+		}
+
+		delay := backoff.Next()
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		n.Logger.Warning("发送 Webhook 通知失败: %v，%v 后重试 (%d/%d)", err, delay.Round(time.Millisecond), backoff.Attempts(), n.Retries)
+		time.Sleep(delay)
+	}
+}
+
+// Body 生成请求体
+func (n *WebhookNotifier) Body(event *WallpaperEvent) ([]byte, error) {
+	if n.Template != nil {
+		var buf bytes.Buffer
+		if err := n.Template.Execute(&buf, event); err != nil {
+			return nil, fmt.Errorf("执行 Webhook 模板失败: %v", err)
+		}
+		if !json.Valid(buf.Bytes()) {
+			return nil, fmt.Errorf("Webhook 模板生成的请求体不是有效的 JSON")
+		}
+		return buf.Bytes(), nil
+	}
+
+	var payload interface{}
+	switch n.Format {
+	case WebhookSlack:
+		payload = slackPayload(event)
+	case WebhookDiscord:
+		payload = discordPayload(event)
+	case WebhookMatrix:
+		payload = matrixPayload(event)
+	default:
+		payload = event
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("编码 Webhook 请求体失败: %v", err)
+	}
+	return data, nil
+}
+
+// SignWebhook 返回请求体的 HMAC-SHA256 签名，形如 sha256=<十六进制摘要>
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post 发送一次请求
+// 返回的 retryAfter 为负数表示不应重试，为正数表示服务端通过 Retry-After 要求的等待时间
+func (n *WebhookNotifier) post(body []byte) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bingWallpaper-webhook")
+	for key, value := range n.Headers {
+		req.Header.Set(key, value)
+	}
+	if n.Secret != "" {
+		header := n.SignatureHeader
+		if header == "" {
+			header = DefaultSignatureHeader
+		}
+		req.Header.Set(header, SignWebhook(n.Secret, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// url.Error 中包含完整的地址，只保留底层错误
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return 0, fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("服务器返回 %s", resp.Status)
+	if text := strings.TrimSpace(string(message)); text != "" {
+		err = fmt.Errorf("服务器返回 %s: %s", resp.Status, text)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return -1, err
+	}
+	if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, err
+	}
+	return 0, err
+}
+
+// redactURL 去掉 URL 中的路径和查询参数，Slack、Discord 等 Webhook 地址本身就是凭据，不应写入日志
+func redactURL(rawURL string) string {
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		if j := strings.Index(rawURL[i+3:], "/"); j >= 0 {
+			return rawURL[:i+3+j] + "/…"
+		}
+	}
+	return rawURL
+}
+
+// eventSummary 返回通知中使用的标题、日期和版权信息
+func eventSummary(event *WallpaperEvent) (title, date, copyright string) {
+	meta := event.Metadata
+	title = meta.Title
+	if title == "" {
+		title = meta.Description
+	}
+	return title, meta.Date, meta.Copyright
+}
+
+// slackPayload 返回 Slack Incoming Webhook 的请求体，text 用于通知预览，blocks 显示标题和图片
+func slackPayload(event *WallpaperEvent) map[string]interface{} {
+	title, date, copyright := eventSummary(event)
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+	heading := "*" + escape(title) + "*"
+	if link := event.Metadata.CopyrightLink; link != "" {
+		heading = "*<" + link + "|" + escape(title) + ">*"
+	}
+	blocks := []map[string]interface{}{{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": heading + "\n" + escape(copyright)},
+	}}
+	if event.Metadata.URL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":      "image",
+			"image_url": event.Metadata.URL,
+			"alt_text":  title,
+		})
+	}
+	blocks = append(blocks, map[string]interface{}{
+		"type":     "context",
+		"elements": []map[string]string{{"type": "mrkdwn", "text": "Bing " + escape(date)}},
+	})
+	return map[string]interface{}{
+		"text":   fmt.Sprintf("%s (%s)", title, date),
+		"blocks": blocks,
+	}
+}
+
+// discordPayload 返回 Discord Webhook 的请求体，以 embed 显示标题、版权和图片
+func discordPayload(event *WallpaperEvent) map[string]interface{} {
+	title, date, copyright := eventSummary(event)
+	embed := map[string]interface{}{
+		"title":       title,
+		"description": copyright,
+		"footer":      map[string]string{"text": "Bing " + date},
+	}
+	if link := event.Metadata.CopyrightLink; link != "" {
+		embed["url"] = link
+	}
+	if event.Metadata.URL != "" {
+		embed["image"] = map[string]string{"url": event.Metadata.URL}
+	}
+	if event.Metadata.Dominant != "" {
+		if color, err := strconv.ParseInt(strings.TrimPrefix(event.Metadata.Dominant, "#"), 16, 32); err == nil {
+			embed["color"] = color
+		}
+	}
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
+
+// matrixPayload 返回 Matrix m.room.message 消息内容，同时提供纯文本和 HTML 两种形式
+func matrixPayload(event *WallpaperEvent) map[string]interface{} {
+	title, date, copyright := eventSummary(event)
+	body := fmt.Sprintf("%s (%s)\n%s", title, date, copyright)
+	formatted := fmt.Sprintf("<strong>%s</strong> (%s)<br>%s", html.EscapeString(title), html.EscapeString(date), html.EscapeString(copyright))
+	if link := event.Metadata.CopyrightLink; link != "" {
+		formatted = fmt.Sprintf(`<strong><a href="%s">%s</a></strong> (%s)<br>%s`, html.EscapeString(link), html.EscapeString(title), html.EscapeString(date), html.EscapeString(copyright))
+	}
+	if event.Metadata.URL != "" {
+		body += "\n" + event.Metadata.URL
+		formatted += fmt.Sprintf(`<br><a href="%s">%s</a>`, html.EscapeString(event.Metadata.URL), html.EscapeString(event.Metadata.URL))
+	}
+	return map[string]interface{}{
+		"msgtype":        "m.text",
+		"body":           body,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	}
+}
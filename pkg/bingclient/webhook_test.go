@@ -0,0 +1,411 @@
+package bingclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testWallpaperEvent 返回测试用的新壁纸事件，标题中包含需要转义的字符
+func testWallpaperEvent() *WallpaperEvent {
+	return &WallpaperEvent{
+		Event:     "new-wallpaper",
+		ImagePath: "/walls/20261001.jpg",
+		Metadata: &ImageMetadata{
+			Date:          "2026-10-01",
+			Title:         "Bled <Island> & Lake",
+			Copyright:     "Lake Bled, Slovenia (© Jane Doe/Getty Images)",
+			CopyrightLink: "https://www.bing.com/search?q=Lake+Bled",
+			URL:           "https://www.bing.com/th?id=OHR.Bled_UHD.jpg",
+			Dominant:      "#336699",
+		},
+	}
+}
+
+// decodeBody 将请求体解码为通用的 JSON 值
+func decodeBody(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("请求体不是有效的 JSON: %v\n%s", err, body)
+	}
+	return v
+}
+
+// jsonPath 按键和下标依次取出嵌套的 JSON 值
+func jsonPath(t *testing.T, v interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				t.Fatalf("%v 不是对象，无法取 %q", v, key)
+			}
+			v = m[key]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || key >= len(a) {
+				t.Fatalf("%v 不是长度大于 %d 的数组", v, key)
+			}
+			v = a[key]
+		}
+	}
+	return v
+}
+
+func TestWebhookBody(t *testing.T) {
+	tests := []struct {
+		format WebhookFormat
+		want   map[string][]interface{} // 期望值到 JSON 路径的映射
+	}{
+		{
+			format: WebhookGeneric,
+			want: map[string][]interface{}{
+				"new-wallpaper":                                 {"event"},
+				"/walls/20261001.jpg":                           {"imagePath"},
+				"Bled <Island> & Lake":                          {"metadata", "title"},
+				"https://www.bing.com/th?id=OHR.Bled_UHD.jpg":   {"metadata", "url"},
+				"Lake Bled, Slovenia (© Jane Doe/Getty Images)": {"metadata", "copyright"},
+			},
+		},
+		{
+			format: WebhookSlack,
+			want: map[string][]interface{}{
+				"Bled <Island> & Lake (2026-10-01)": {"text"},
+				"*<https://www.bing.com/search?q=Lake+Bled|Bled &lt;Island&gt; &amp; Lake>*\nLake Bled, Slovenia (© Jane Doe/Getty Images)": {"blocks", 0, "text", "text"},
+				"image": {"blocks", 1, "type"},
+				"https://www.bing.com/th?id=OHR.Bled_UHD.jpg": {"blocks", 1, "image_url"},
+				"Bing 2026-10-01": {"blocks", 2, "elements", 0, "text"},
+			},
+		},
+		{
+			format: WebhookDiscord,
+			want: map[string][]interface{}{
+				"Bled <Island> & Lake":                          {"embeds", 0, "title"},
+				"Lake Bled, Slovenia (© Jane Doe/Getty Images)": {"embeds", 0, "description"},
+				"https://www.bing.com/search?q=Lake+Bled":       {"embeds", 0, "url"},
+				"https://www.bing.com/th?id=OHR.Bled_UHD.jpg":   {"embeds", 0, "image", "url"},
+				"Bing 2026-10-01":                               {"embeds", 0, "footer", "text"},
+			},
+		},
+		{
+			format: WebhookMatrix,
+			want: map[string][]interface{}{
+				"m.text":                 {"msgtype"},
+				"org.matrix.custom.html": {"format"},
+				"Bled <Island> & Lake (2026-10-01)\nLake Bled, Slovenia (© Jane Doe/Getty Images)\nhttps://www.bing.com/th?id=OHR.Bled_UHD.jpg":                                                                                                                                                {"body"},
+				`<strong><a href="https://www.bing.com/search?q=Lake+Bled">Bled &lt;Island&gt; &amp; Lake</a></strong> (2026-10-01)<br>Lake Bled, Slovenia (© Jane Doe/Getty Images)<br><a href="https://www.bing.com/th?id=OHR.Bled_UHD.jpg">https://www.bing.com/th?id=OHR.Bled_UHD.jpg</a>`: {"formatted_body"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			body, err := NewWebhookNotifier("http://example.invalid", tt.format, nil).Body(testWallpaperEvent())
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := decodeBody(t, body)
+			for want, path := range tt.want {
+				if got := jsonPath(t, v, path...); got != want {
+					t.Errorf("%v = %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookBodyDiscordColor(t *testing.T) {
+	body, err := NewWebhookNotifier("http://example.invalid", WebhookDiscord, nil).Body(testWallpaperEvent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := jsonPath(t, decodeBody(t, body), "embeds", 0, "color"); got != float64(0x336699) {
+		t.Errorf("color = %v, want %d", got, 0x336699)
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string // 期望的请求体，为空表示应返回错误
+	}{
+		{
+			name:     "json 函数转义字符串",
+			template: `{"text": {{json .Metadata.Title}}, "image": {{json .Metadata.URL}}}`,
+			want:     `{"text": "Bled \u003cIsland\u003e \u0026 Lake", "image": "https://www.bing.com/th?id=OHR.Bled_UHD.jpg"}`,
+		},
+		{
+			name:     "输出不是 JSON",
+			template: `新壁纸: {{.Metadata.Title}}`,
+		},
+		{
+			name:     "未使用 json 函数导致 JSON 无效",
+			template: `{"text": "{{.Metadata.Copyright}}" "x"}`,
+		},
+		{
+			name:     "引用不存在的字段",
+			template: `{"text": {{json .Metadata.Missing}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseWebhookTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			notifier := NewWebhookNotifier("http://example.invalid", WebhookSlack, nil)
+			notifier.Template = tmpl
+			body, err := notifier.Body(testWallpaperEvent())
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("应返回错误，实际请求体为 %s", body)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("请求体\n got: %s\nwant: %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWebhookTemplateSyntaxError(t *testing.T) {
+	if _, err := ParseWebhookTemplate(`{"text": {{json .Metadata.Title}`); err == nil {
+		t.Error("模板语法错误时应返回错误")
+	}
+}
+
+func TestWebhookNotifySignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string // SignatureHeader
+		want   string // 实际使用的请求头
+	}{
+		{name: "默认请求头", want: DefaultSignatureHeader},
+		{name: "自定义请求头", header: "X-Hub-Signature-256", want: "X-Hub-Signature-256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				gotBody, _ = io.ReadAll(r.Body)
+			}))
+			defer server.Close()
+
+			notifier := NewWebhookNotifier(server.URL+"/hook", WebhookGeneric, nil)
+			notifier.Secret = "s3cret"
+			notifier.SignatureHeader = tt.header
+			notifier.Headers = map[string]string{"Authorization": "Bearer token"}
+			if err := notifier.Notify(testWallpaperEvent()); err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := notifier.Body(testWallpaperEvent())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(gotBody) != string(want) {
+				t.Errorf("请求体\n got: %s\nwant: %s", gotBody, want)
+			}
+			if got.Method != http.MethodPost || got.URL.Path != "/hook" {
+				t.Errorf("请求为 %s %s", got.Method, got.URL.Path)
+			}
+			if ct := got.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if auth := got.Header.Get("Authorization"); auth != "Bearer token" {
+				t.Errorf("Authorization = %q", auth)
+			}
+			if sig := got.Header.Get(tt.want); sig != SignWebhook("s3cret", gotBody) {
+				t.Errorf("%s = %q, want %q", tt.want, sig, SignWebhook("s3cret", gotBody))
+			}
+			if !strings.HasPrefix(got.Header.Get(tt.want), "sha256=") {
+				t.Errorf("签名应以 sha256= 开头: %q", got.Header.Get(tt.want))
+			}
+		})
+	}
+}
+
+func TestWebhookNotifyUnsigned(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL, WebhookGeneric, nil).Notify(testWallpaperEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if sig := header.Get(DefaultSignatureHeader); sig != "" {
+		t.Errorf("没有密钥时不应签名: %q", sig)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// RFC 4231 测试用例 2
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got := SignWebhook("Jefe", []byte("what do ya want for nothing?")); got != want {
+		t.Errorf("SignWebhook() = %q, want %q", got, want)
+	}
+}
+
+// webhookResponse 是假服务器的一次响应
+type webhookResponse struct {
+	status     int
+	retryAfter string
+}
+
+func TestWebhookNotifyRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []webhookResponse
+		retries   int
+		wantCalls int
+		wantErr   bool
+		minWait   time.Duration // 至少应等待的时间
+	}{
+		{
+			name:      "成功不重试",
+			responses: []webhookResponse{{status: 204}},
+			retries:   3,
+			wantCalls: 1,
+		},
+		{
+			name:      "5xx 后重试成功",
+			responses: []webhookResponse{{status: 500}, {status: 502}, {status: 200}},
+			retries:   3,
+			wantCalls: 3,
+		},
+		{
+			name:      "429 遵循 Retry-After",
+			responses: []webhookResponse{{status: 429, retryAfter: "1"}, {status: 200}},
+			retries:   3,
+			wantCalls: 2,
+			minWait:   time.Second,
+		},
+		{
+			name:      "Retry-After 超过最长等待时间时放弃",
+			responses: []webhookResponse{{status: 429, retryAfter: "3600"}, {status: 200}},
+			retries:   3,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "其他 4xx 不重试",
+			responses: []webhookResponse{{status: 400}, {status: 200}},
+			retries:   3,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "404 不重试",
+			responses: []webhookResponse{{status: 404}, {status: 200}},
+			retries:   3,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "重试次数用尽",
+			responses: []webhookResponse{{status: 503}, {status: 503}, {status: 503}, {status: 503}},
+			retries:   2,
+			wantCalls: 3,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				resp := tt.responses[min(calls, len(tt.responses)-1)]
+				calls++
+				mu.Unlock()
+				if resp.retryAfter != "" {
+					w.Header().Set("Retry-After", resp.retryAfter)
+				}
+				w.WriteHeader(resp.status)
+			}))
+			defer server.Close()
+
+			notifier := NewWebhookNotifier(server.URL, WebhookGeneric, nil)
+			notifier.Retries = tt.retries
+			notifier.RetryDelay = time.Millisecond
+			notifier.MaxRetryDelay = 2 * time.Second
+
+			start := time.Now()
+			err := notifier.Notify(testWallpaperEvent())
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("请求次数 = %d, want %d", calls, tt.wantCalls)
+			}
+			if elapsed < tt.minWait {
+				t.Errorf("等待了 %v，应至少等待 %v", elapsed, tt.minWait)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifyErrorRedactsURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL+"/services/T000/SECRET", WebhookSlack, nil).Notify(testWallpaperEvent())
+	if err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Fatalf("错误中应包含服务器的响应: %v", err)
+	}
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("错误中不应包含 Webhook 地址: %v", err)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := map[string]string{
+		"https://hooks.slack.com/services/T000/B000/XXXX": "https://hooks.slack.com/…",
+		"https://example.com":                             "https://example.com",
+		"not a url":                                       "not a url",
+	}
+	for in, want := range tests {
+		if got := redactURL(in); got != want {
+			t.Errorf("redactURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWebhookEventFromResult(t *testing.T) {
+	result := &DownloadResult{
+		ImageData: ImageData{Title: "Lake Bled", Copyright: "Lake Bled, Slovenia (© Jane Doe/Getty Images)"},
+		ImagePath: "/walls/a.jpg",
+		JsonPath:  "/walls/a.json",
+		Variants:  []*SavedVariant{{Kind: VariantThumbnail, Name: "320w", Path: "/walls/thumbs/a_320w.jpg", Width: 320, Height: 180}},
+	}
+	event := NewWallpaperEvent(result)
+	got := []string{event.Event, event.ImagePath, event.MetadataPath, event.Metadata.Title, event.Variants[0].Name}
+	want := []string{"new-wallpaper", "/walls/a.jpg", "/walls/a.json", "Lake Bled", "320w"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewWallpaperEvent() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package wallpaper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CommandSetter 通过通用的命令行工具设置壁纸，适用于没有桌面环境的窗口管理器
+type CommandSetter struct {
+	Tool     string          // 设置方式名称
+	Command  string          // 命令
+	Args     []string        // 参数，其中的 {path} 会被替换为壁纸路径
+	Monitor  *MonitorCommand // 为每台显示器设置壁纸的参数，为 nil 表示不支持
+	SpanArgs []string        // 将壁纸跨越所有显示器显示的参数，为空表示不支持
+}
+
+// MonitorCommand 描述如何通过命令行工具为每台显示器设置壁纸
+type MonitorCommand struct {
+	Prefix []string // 命令开头的参数
+	Args   []string // 每台显示器的参数，其中的 {path}、{name}、{index} 会被替换为壁纸路径、显示器名称和序号
+	Batch  bool     // 是否在一条命令中按序号依次给出所有显示器的参数，否则每台显示器执行一次命令
+}
+
+// NewCommandSetter 创建通用命令行工具的设置方式
+func NewCommandSetter(name, command string, args ...string) *CommandSetter {
+	return &CommandSetter{Tool: name, Command: command, Args: args}
+}
+
+// fallbackSetters 返回通用命令行工具的设置方式，按优先级排列
+func fallbackSetters() []*CommandSetter {
+	feh := NewCommandSetter("feh", "feh", "--bg-fill", "{path}")
+	feh.Monitor = &MonitorCommand{Prefix: []string{"--bg-fill"}, Args: []string{"{path}"}, Batch: true}
+	feh.SpanArgs = []string{"--bg-fill", "--no-xinerama", "{path}"}
+
+	nitrogen := NewCommandSetter("nitrogen", "nitrogen", "--set-zoom-fill", "--save", "{path}")
+	nitrogen.Monitor = &MonitorCommand{Args: []string{"--head={index}", "--set-zoom-fill", "--save", "{path}"}}
+
+	pcmanfm := NewCommandSetter("pcmanfm", "pcmanfm", "--set-wallpaper={path}")
+	pcmanfm.SpanArgs = []string{"--set-wallpaper={path}", "--wallpaper-mode=screen"}
+
+	xwallpaper := NewCommandSetter("xwallpaper", "xwallpaper", "--zoom", "{path}")
+	xwallpaper.Monitor = &MonitorCommand{Args: []string{"--output", "{name}", "--zoom", "{path}"}, Batch: true}
+
+	return []*CommandSetter{
+		feh,
+		nitrogen,
+		pcmanfm,
+		NewCommandSetter("hsetroot", "hsetroot", "-fill", "{path}"),
+		xwallpaper,
+	}
+}
+
+// Name 返回设置方式的名称
+func (s *CommandSetter) Name() string {
+	return s.Tool
+}
+
+// Available 判断命令是否可用
+func (s *CommandSetter) Available() bool {
+	return commandExists(s.Command)
+}
+
+// Set 执行命令设置壁纸
+func (s *CommandSetter) Set(img Image) error {
+	return run(s.Command, expandArgs(s.Args, img.Path, Monitor{})...)
+}
+
+// SetMonitors 执行命令为每台显示器设置壁纸
+func (s *CommandSetter) SetMonitors(layout *Layout, images map[string]Image) error {
+	if s.Monitor == nil {
+		return fmt.Errorf("%s %w", s.Tool, ErrUnsupported)
+	}
+	args := append([]string(nil), s.Monitor.Prefix...)
+	for _, m := range layout.byIndex() {
+		monitorArgs := expandArgs(s.Monitor.Args, images[m.Name].Path, m)
+		if s.Monitor.Batch {
+			args = append(args, monitorArgs...)
+			continue
+		}
+		if err := run(s.Command, append(append([]string(nil), s.Monitor.Prefix...), monitorArgs...)...); err != nil {
+			return err
+		}
+	}
+	if !s.Monitor.Batch {
+		return nil
+	}
+	return run(s.Command, args...)
+}
+
+// SetSpanned 执行命令将壁纸跨越所有显示器显示
+func (s *CommandSetter) SetSpanned(img Image) error {
+	if len(s.SpanArgs) == 0 {
+		return fmt.Errorf("%s %w", s.Tool, ErrUnsupported)
+	}
+	return run(s.Command, expandArgs(s.SpanArgs, img.Path, Monitor{})...)
+}
+
+// expandArgs 替换参数中的 {path}、{name} 和 {index}
+func expandArgs(args []string, path string, m Monitor) []string {
+	replacer := strings.NewReplacer("{path}", path, "{name}", m.Name, "{index}", strconv.Itoa(m.Index))
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = replacer.Replace(arg)
+	}
+	return expanded
+}
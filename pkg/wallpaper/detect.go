@@ -0,0 +1,74 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// desktopSetters 将 XDG_CURRENT_DESKTOP 中的桌面名称（小写）映射到设置方式
+var desktopSetters = map[string]string{
+	"gnome":      "gnome",
+	"gnome-xorg": "gnome",
+	"ubuntu":     "gnome",
+	"pop":        "gnome",
+	"unity":      "gnome",
+	"budgie":     "gnome",
+	"cinnamon":   "cinnamon",
+	"x-cinnamon": "cinnamon",
+	"mate":       "mate",
+	"kde":        "kde",
+	"plasma":     "kde",
+	"xfce":       "xfce",
+	"sway":       "sway",
+	"hyprland":   "hyprland",
+}
+
+// Detect 根据当前环境选择设置方式，见 DetectFromEnv
+func Detect() (Setter, error) {
+	return DetectFromEnv(os.Getenv)
+}
+
+// DetectFromEnv 根据环境变量选择设置方式
+// 依次检查 XDG_CURRENT_DESKTOP（可能包含以冒号分隔的多个名称）、DESKTOP_SESSION、SWAYSOCK 和
+// HYPRLAND_INSTANCE_SIGNATURE；无法识别桌面或所需命令不可用时，退回第一个可用的 feh、nitrogen 等通用工具
+func DetectFromEnv(getenv func(string) string) (Setter, error) {
+	var candidates []string
+	for _, name := range strings.Split(getenv("XDG_CURRENT_DESKTOP"), ":") {
+		candidates = append(candidates, strings.ToLower(strings.TrimSpace(name)))
+	}
+	candidates = append(candidates, strings.ToLower(getenv("DESKTOP_SESSION")))
+	if getenv("SWAYSOCK") != "" {
+		candidates = append(candidates, "sway")
+	}
+	if getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" {
+		candidates = append(candidates, "hyprland")
+	}
+
+	var unavailable []string
+	for _, candidate := range candidates {
+		name, ok := desktopSetters[candidate]
+		if !ok {
+			continue
+		}
+		setter, err := New(name)
+		if err != nil {
+			return nil, err
+		}
+		if setter.Available() {
+			return setter, nil
+		}
+		unavailable = append(unavailable, name)
+	}
+
+	for _, setter := range fallbackSetters() {
+		if setter.Available() {
+			return setter, nil
+		}
+	}
+
+	if len(unavailable) > 0 {
+		return nil, fmt.Errorf("检测到桌面 %s，但所需的命令不可用", strings.Join(unavailable, ", "))
+	}
+	return nil, fmt.Errorf("无法检测到支持的桌面环境或壁纸设置工具，请安装 feh、nitrogen 等工具或使用 -setter 指定")
+}
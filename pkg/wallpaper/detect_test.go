@@ -0,0 +1,125 @@
+package wallpaper
+
+import "testing"
+
+func TestDetectFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		commands []string // PATH 中存在的命令
+		want     string   // 期望的设置方式，为空表示应返回错误
+	}{
+		{
+			name:     "GNOME",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "GNOME"},
+			commands: []string{"gsettings", "feh"},
+			want:     "gnome",
+		},
+		{
+			name:     "Ubuntu 的多个桌面名称",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "ubuntu:GNOME"},
+			commands: []string{"gsettings"},
+			want:     "gnome",
+		},
+		{
+			name:     "Cinnamon",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "X-Cinnamon"},
+			commands: []string{"gsettings"},
+			want:     "cinnamon",
+		},
+		{
+			name:     "MATE",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "MATE"},
+			commands: []string{"gsettings"},
+			want:     "mate",
+		},
+		{
+			name:     "KDE",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "KDE"},
+			commands: []string{"qdbus"},
+			want:     "kde",
+		},
+		{
+			name:     "XFCE",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "XFCE"},
+			commands: []string{"xfconf-query"},
+			want:     "xfce",
+		},
+		{
+			name:     "只有 DESKTOP_SESSION",
+			env:      map[string]string{"DESKTOP_SESSION": "plasma"},
+			commands: []string{"dbus-send"},
+			want:     "kde",
+		},
+		{
+			name:     "sway",
+			env:      map[string]string{"SWAYSOCK": "/run/user/1000/sway-ipc.sock"},
+			commands: []string{"swaymsg"},
+			want:     "sway",
+		},
+		{
+			name:     "Hyprland",
+			env:      map[string]string{"HYPRLAND_INSTANCE_SIGNATURE": "abc"},
+			commands: []string{"hyprctl"},
+			want:     "hyprland",
+		},
+		{
+			name:     "桌面名称优先于 SWAYSOCK",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "GNOME", "SWAYSOCK": "/tmp/sway.sock"},
+			commands: []string{"gsettings", "swaymsg"},
+			want:     "gnome",
+		},
+		{
+			name:     "桌面所需的命令不可用时检查下一个候选",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "KDE", "SWAYSOCK": "/tmp/sway.sock"},
+			commands: []string{"swaymsg"},
+			want:     "sway",
+		},
+		{
+			name:     "未知桌面退回通用工具",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "i3"},
+			commands: []string{"nitrogen", "xwallpaper"},
+			want:     "nitrogen",
+		},
+		{
+			name:     "通用工具按优先级选择",
+			env:      map[string]string{},
+			commands: []string{"xwallpaper", "feh"},
+			want:     "feh",
+		},
+		{
+			name:     "桌面所需的命令不可用且没有通用工具",
+			env:      map[string]string{"XDG_CURRENT_DESKTOP": "GNOME"},
+			commands: nil,
+		},
+		{
+			name:     "无法识别桌面且没有通用工具",
+			env:      map[string]string{},
+			commands: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts := make(map[string]string)
+			for _, name := range tt.commands {
+				scripts[name] = ""
+			}
+			fakeCommands(t, scripts)
+
+			setter, err := DetectFromEnv(func(key string) string { return tt.env[key] })
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("应返回错误，实际选择了 %s", setter.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if setter.Name() != tt.want {
+				t.Errorf("DetectFromEnv() = %s, want %s", setter.Name(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package wallpaper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GSettingsSetter 通过 gsettings 设置 GNOME 系桌面（GNOME、Cinnamon、MATE）的壁纸
+type GSettingsSetter struct {
+	Desktop string // 设置方式名称
+	Schema  string // gsettings schema，如 org.gnome.desktop.background
+	Key     string // 壁纸键，如 picture-uri
+	DarkKey string // 深色模式的壁纸键，为空表示不支持深色模式
+	URI     bool   // 值是否为 file:// URI，否则为文件路径
+
+	// OptionsKey 是缩放方式的键，如 picture-options，跨屏显示时设置为 spanned
+	OptionsKey string
+}
+
+// NewGNOMESetter 创建 GNOME 壁纸设置方式，同时设置浅色和深色模式的壁纸
+func NewGNOMESetter() *GSettingsSetter {
+	return &GSettingsSetter{
+		Desktop: "gnome",
+		Schema:  "org.gnome.desktop.background",
+		Key:     "picture-uri",
+		DarkKey: "picture-uri-dark",
+		URI:     true,
+
+		OptionsKey: "picture-options",
+	}
+}
+
+// NewCinnamonSetter 创建 Cinnamon 壁纸设置方式
+func NewCinnamonSetter() *GSettingsSetter {
+	return &GSettingsSetter{
+		Desktop: "cinnamon",
+		Schema:  "org.cinnamon.desktop.background",
+		Key:     "picture-uri",
+		URI:     true,
+
+		OptionsKey: "picture-options",
+	}
+}
+
+// NewMATESetter 创建 MATE 壁纸设置方式
+func NewMATESetter() *GSettingsSetter {
+	return &GSettingsSetter{
+		Desktop: "mate",
+		Schema:  "org.mate.background",
+		Key:     "picture-filename",
+
+		OptionsKey: "picture-options",
+	}
+}
+
+// Name 返回设置方式的名称
+func (s *GSettingsSetter) Name() string {
+	return s.Desktop
+}
+
+// Available 判断 gsettings 是否可用
+func (s *GSettingsSetter) Available() bool {
+	return commandExists("gsettings")
+}
+
+// Set 设置壁纸；GNOME 42 之前没有深色模式的键，此时只设置浅色模式的壁纸
+// 如果之前设置过跨屏显示，会恢复为默认的 zoom 缩放方式
+func (s *GSettingsSetter) Set(img Image) error {
+	if s.OptionsKey != "" {
+		if current, err := output("gsettings", "get", s.Schema, s.OptionsKey); err == nil && strings.Contains(current, "spanned") {
+			if err := run("gsettings", "set", s.Schema, s.OptionsKey, "zoom"); err != nil {
+				return err
+			}
+		}
+	}
+	return s.set(img)
+}
+
+// SetSpanned 将壁纸的缩放方式设置为 spanned，使整幅画面跨越所有显示器
+func (s *GSettingsSetter) SetSpanned(img Image) error {
+	if s.OptionsKey == "" {
+		return fmt.Errorf("%s %w", s.Desktop, ErrUnsupported)
+	}
+	if err := run("gsettings", "set", s.Schema, s.OptionsKey, "spanned"); err != nil {
+		return err
+	}
+	return s.set(img)
+}
+
+// set 写入壁纸键
+func (s *GSettingsSetter) set(img Image) error {
+	if err := run("gsettings", "set", s.Schema, s.Key, s.value(img.Path)); err != nil {
+		return err
+	}
+	if s.DarkKey == "" {
+		return nil
+	}
+	if err := run("gsettings", "set", s.Schema, s.DarkKey, s.value(img.dark())); err != nil && !strings.Contains(err.Error(), "No such key") {
+		return err
+	}
+	return nil
+}
+
+// value 返回写入 gsettings 的值
+func (s *GSettingsSetter) value(path string) string {
+	if s.URI {
+		return fileURI(path)
+	}
+	return path
+}
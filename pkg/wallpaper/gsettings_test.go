@@ -0,0 +1,134 @@
+package wallpaper
+
+import (
+	"strings"
+	"testing"
+)
+
+// gsettings 的 picture-options 为 zoom，其他键正常写入
+const gsettingsZoom = `[ "$1" = get ] && echo "'zoom'"; exit 0`
+
+func TestGSettingsSetter(t *testing.T) {
+	tests := []struct {
+		name   string
+		setter *GSettingsSetter
+		script string
+		apply  func(s *GSettingsSetter) error
+		want   [][]string
+	}{
+		{
+			name:   "GNOME 同时设置浅色和深色壁纸",
+			setter: NewGNOMESetter(),
+			script: gsettingsZoom,
+			apply: func(s *GSettingsSetter) error {
+				return s.Set(Image{Path: "/walls/day.jpg", DarkPath: "/walls/night.jpg"})
+			},
+			want: [][]string{
+				{"gsettings", "get", "org.gnome.desktop.background", "picture-options"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", "file:///walls/day.jpg"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", "file:///walls/night.jpg"},
+			},
+		},
+		{
+			name:   "GNOME 没有深色壁纸时使用同一张",
+			setter: NewGNOMESetter(),
+			script: gsettingsZoom,
+			apply:  func(s *GSettingsSetter) error { return s.Set(Image{Path: "/walls/a b.jpg"}) },
+			want: [][]string{
+				{"gsettings", "get", "org.gnome.desktop.background", "picture-options"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", "file:///walls/a%20b.jpg"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", "file:///walls/a%20b.jpg"},
+			},
+		},
+		{
+			name:   "GNOME 42 之前没有深色模式的键",
+			setter: NewGNOMESetter(),
+			script: `[ "$1" = get ] && echo "'zoom'"
+[ "$3" = picture-uri-dark ] && { echo 'No such key “picture-uri-dark”' >&2; exit 1; }
+exit 0`,
+			apply: func(s *GSettingsSetter) error { return s.Set(Image{Path: "/walls/day.jpg"}) },
+			want: [][]string{
+				{"gsettings", "get", "org.gnome.desktop.background", "picture-options"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", "file:///walls/day.jpg"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", "file:///walls/day.jpg"},
+			},
+		},
+		{
+			name:   "之前跨屏显示时恢复为 zoom",
+			setter: NewGNOMESetter(),
+			script: `[ "$1" = get ] && echo "'spanned'"; exit 0`,
+			apply:  func(s *GSettingsSetter) error { return s.Set(Image{Path: "/walls/day.jpg"}) },
+			want: [][]string{
+				{"gsettings", "get", "org.gnome.desktop.background", "picture-options"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-options", "zoom"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", "file:///walls/day.jpg"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", "file:///walls/day.jpg"},
+			},
+		},
+		{
+			name:   "GNOME 跨屏",
+			setter: NewGNOMESetter(),
+			script: gsettingsZoom,
+			apply:  func(s *GSettingsSetter) error { return s.SetSpanned(Image{Path: "/walls/wide.jpg"}) },
+			want: [][]string{
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-options", "spanned"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri", "file:///walls/wide.jpg"},
+				{"gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", "file:///walls/wide.jpg"},
+			},
+		},
+		{
+			name:   "Cinnamon 没有深色模式",
+			setter: NewCinnamonSetter(),
+			script: gsettingsZoom,
+			apply: func(s *GSettingsSetter) error {
+				return s.Set(Image{Path: "/walls/day.jpg", DarkPath: "/walls/night.jpg"})
+			},
+			want: [][]string{
+				{"gsettings", "get", "org.cinnamon.desktop.background", "picture-options"},
+				{"gsettings", "set", "org.cinnamon.desktop.background", "picture-uri", "file:///walls/day.jpg"},
+			},
+		},
+		{
+			name:   "MATE 使用文件路径",
+			setter: NewMATESetter(),
+			script: gsettingsZoom,
+			apply:  func(s *GSettingsSetter) error { return s.Set(Image{Path: "/walls/a b.jpg"}) },
+			want: [][]string{
+				{"gsettings", "get", "org.mate.background", "picture-options"},
+				{"gsettings", "set", "org.mate.background", "picture-filename", "/walls/a b.jpg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeCommands(t, map[string]string{"gsettings": tt.script})
+			if err := tt.apply(tt.setter); err != nil {
+				t.Fatal(err)
+			}
+			assertCalls(t, calls(), tt.want)
+		})
+	}
+}
+
+func TestGSettingsSetterDarkKeyError(t *testing.T) {
+	// 深色模式的键存在但写入失败时，不能当作旧版 GNOME 忽略
+	fakeCommands(t, map[string]string{"gsettings": `[ "$1" = get ] && echo "'zoom'"
+[ "$3" = picture-uri-dark ] && { echo 'Permission denied' >&2; exit 1; }
+exit 0`})
+
+	err := NewGNOMESetter().Set(Image{Path: "/walls/day.jpg"})
+	if err == nil || !strings.Contains(err.Error(), "Permission denied") {
+		t.Errorf("应返回深色模式键的写入错误，实际为 %v", err)
+	}
+}
+
+func TestGSettingsSetterUnsupportedSpan(t *testing.T) {
+	fakeCommands(t, map[string]string{"gsettings": ""})
+
+	setter := NewGNOMESetter()
+	setter.OptionsKey = ""
+	if err := setter.SetSpanned(Image{Path: "/walls/wide.jpg"}); err == nil {
+		t.Error("没有缩放方式的键时应返回错误")
+	}
+}
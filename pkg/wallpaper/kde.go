@@ -0,0 +1,92 @@
+package wallpaper
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// qdbus 在不同发行版和 Qt 版本中的命令名
+var qdbusCommands = []string{"qdbus6", "qdbus-qt6", "qdbus", "qdbus-qt5"}
+
+// KDESetter 通过 Plasma Shell 的 D-Bus 脚本接口设置 KDE Plasma 的壁纸
+// 优先使用 qdbus，没有时退回 dbus-send
+type KDESetter struct{}
+
+// NewKDESetter 创建 KDE Plasma 壁纸设置方式
+func NewKDESetter() *KDESetter {
+	return &KDESetter{}
+}
+
+// Name 返回设置方式的名称
+func (s *KDESetter) Name() string {
+	return "kde"
+}
+
+// Available 判断 qdbus 或 dbus-send 是否可用
+func (s *KDESetter) Available() bool {
+	return s.qdbus() != "" || commandExists("dbus-send")
+}
+
+// Set 为所有桌面设置壁纸
+func (s *KDESetter) Set(img Image) error {
+	return s.evaluate(plasmaScript(img.Path))
+}
+
+// SetMonitors 按桌面所在屏幕的位置为每台显示器设置壁纸
+func (s *KDESetter) SetMonitors(layout *Layout, images map[string]Image) error {
+	uris := make(map[string]string, len(layout.Monitors))
+	for _, m := range layout.Monitors {
+		uris[fmt.Sprintf("%d,%d", m.X, m.Y)] = fileURI(images[m.Name].Path)
+	}
+	return s.evaluate(plasmaMonitorScript(uris))
+}
+
+// evaluate 通过 Plasma Shell 执行脚本
+func (s *KDESetter) evaluate(script string) error {
+	if qdbus := s.qdbus(); qdbus != "" {
+		return run(qdbus, "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", script)
+	}
+	return run("dbus-send", "--session", "--dest=org.kde.plasmashell", "--type=method_call",
+		"/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", "string:"+script)
+}
+
+// qdbus 返回可用的 qdbus 命令，没有时返回空字符串
+func (s *KDESetter) qdbus() string {
+	for _, name := range qdbusCommands {
+		if commandExists(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// plasmaScript 返回为所有桌面设置壁纸的 Plasma 脚本
+func plasmaScript(path string) string {
+	// JSON 字符串也是合法的 JavaScript 字符串字面量，可以安全地嵌入路径
+	uri, _ := json.Marshal(fileURI(path))
+	return fmt.Sprintf(`var allDesktops = desktops();
+for (var i = 0; i < allDesktops.length; i++) {
+    var d = allDesktops[i];
+    d.wallpaperPlugin = "org.kde.image";
+    d.currentConfigGroup = Array("Wallpaper", "org.kde.image", "General");
+    d.writeConfig("Image", %s);
+}`, uri)
+}
+
+// plasmaMonitorScript 返回按屏幕位置设置壁纸的 Plasma 脚本，uris 以 "x,y" 形式的屏幕左上角坐标为键
+func plasmaMonitorScript(uris map[string]string) string {
+	images, _ := json.Marshal(uris)
+	return fmt.Sprintf(`var images = %s;
+var allDesktops = desktops();
+for (var i = 0; i < allDesktops.length; i++) {
+    var d = allDesktops[i];
+    var g = screenGeometry(d.screen);
+    var uri = images[g.x + "," + g.y];
+    if (!uri) {
+        continue;
+    }
+    d.wallpaperPlugin = "org.kde.image";
+    d.currentConfigGroup = Array("Wallpaper", "org.kde.image", "General");
+    d.writeConfig("Image", uri);
+}`, images)
+}
@@ -0,0 +1,88 @@
+package wallpaper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKDESetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		wantCall []string // 脚本之前的参数
+		prefix   string   // 脚本参数的前缀
+	}{
+		{
+			name:     "qdbus",
+			commands: []string{"qdbus", "dbus-send"},
+			wantCall: []string{"qdbus", "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript"},
+		},
+		{
+			name:     "优先使用 Qt 6 的 qdbus6",
+			commands: []string{"qdbus6", "qdbus"},
+			wantCall: []string{"qdbus6", "org.kde.plasmashell", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript"},
+		},
+		{
+			name:     "没有 qdbus 时使用 dbus-send",
+			commands: []string{"dbus-send"},
+			wantCall: []string{"dbus-send", "--session", "--dest=org.kde.plasmashell", "--type=method_call", "/PlasmaShell", "org.kde.PlasmaShell.evaluateScript"},
+			prefix:   "string:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts := make(map[string]string)
+			for _, name := range tt.commands {
+				scripts[name] = ""
+			}
+			calls := fakeCommands(t, scripts)
+
+			setter := NewKDESetter()
+			if !setter.Available() {
+				t.Fatal("命令存在时应可用")
+			}
+			if err := setter.Set(Image{Path: `/walls/"a".jpg`}); err != nil {
+				t.Fatal(err)
+			}
+
+			got := calls()
+			if len(got) != 1 || len(got[0]) != len(tt.wantCall)+1 {
+				t.Fatalf("调用记录不一致: %q", got)
+			}
+			assertCalls(t, [][]string{got[0][:len(tt.wantCall)]}, [][]string{tt.wantCall})
+
+			script := got[0][len(tt.wantCall)]
+			if !strings.HasPrefix(script, tt.prefix) {
+				t.Errorf("脚本参数应以 %q 开头: %q", tt.prefix, script)
+			}
+			if want := `d.writeConfig("Image", "file:///walls/%22a%22.jpg");`; !strings.Contains(script, want) {
+				t.Errorf("脚本中缺少 %s:\n%s", want, script)
+			}
+		})
+	}
+}
+
+func TestKDESetterMonitors(t *testing.T) {
+	calls := fakeCommands(t, map[string]string{"qdbus": ""})
+
+	if err := NewKDESetter().SetMonitors(testLayout, testImages); err != nil {
+		t.Fatal(err)
+	}
+	got := calls()
+	if len(got) != 1 || len(got[0]) != 5 {
+		t.Fatalf("调用记录不一致: %q", got)
+	}
+	want := `var images = {"0,0":"file:///walls/left.jpg","2560,0":"file:///walls/right.jpg"};`
+	if !strings.HasPrefix(got[0][4], want) {
+		t.Errorf("脚本应以 %s 开头:\n%s", want, got[0][4])
+	}
+}
+
+func TestKDESetterUnavailable(t *testing.T) {
+	fakeCommands(t, nil)
+
+	if NewKDESetter().Available() {
+		t.Error("没有 qdbus 和 dbus-send 时不应可用")
+	}
+}
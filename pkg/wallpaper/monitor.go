@@ -0,0 +1,289 @@
+package wallpaper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Monitor 是一台显示器（输出）
+type Monitor struct {
+	Name    string  `json:"name"`              // 输出名称，如 HDMI-1、eDP-1
+	Index   int     `json:"index"`             // 序号（Xinerama 顺序），feh、nitrogen 按序号区分显示器
+	X       int     `json:"x"`                 // 在桌面中的横坐标（逻辑像素）
+	Y       int     `json:"y"`                 // 在桌面中的纵坐标（逻辑像素）
+	Width   int     `json:"width"`             // 水平分辨率（物理像素，已考虑旋转）
+	Height  int     `json:"height"`            // 垂直分辨率（物理像素，已考虑旋转）
+	Scale   float64 `json:"scale,omitempty"`   // 缩放比例，为 0 时视为 1
+	Primary bool    `json:"primary,omitempty"` // 是否为主显示器
+}
+
+// LogicalBounds 返回显示器在桌面中占据的区域（逻辑像素）
+func (m Monitor) LogicalBounds() image.Rectangle {
+	scale := m.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	w := int(math.Round(float64(m.Width) / scale))
+	h := int(math.Round(float64(m.Height) / scale))
+	return image.Rect(m.X, m.Y, m.X+w, m.Y+h)
+}
+
+// Layout 是显示器布局
+type Layout struct {
+	Monitors []Monitor `json:"monitors"` // 显示器
+}
+
+// Bounds 返回所有显示器组成的桌面区域（逻辑像素）
+func (l *Layout) Bounds() image.Rectangle {
+	var bounds image.Rectangle
+	for i, m := range l.Monitors {
+		if i == 0 {
+			bounds = m.LogicalBounds()
+			continue
+		}
+		bounds = bounds.Union(m.LogicalBounds())
+	}
+	return bounds
+}
+
+// Ordered 返回按主显示器优先、再从上到下、从左到右排列的显示器
+func (l *Layout) Ordered() []Monitor {
+	monitors := append([]Monitor(nil), l.Monitors...)
+	sort.SliceStable(monitors, func(i, j int) bool {
+		a, b := monitors[i], monitors[j]
+		if a.Primary != b.Primary {
+			return a.Primary
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return monitors
+}
+
+// Validate 检查布局是否有效
+func (l *Layout) Validate() error {
+	if len(l.Monitors) == 0 {
+		return fmt.Errorf("显示器布局为空")
+	}
+	seen := make(map[string]bool)
+	for _, m := range l.Monitors {
+		if m.Name == "" {
+			return fmt.Errorf("显示器缺少名称")
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("显示器名称重复: %s", m.Name)
+		}
+		seen[m.Name] = true
+		if m.Width <= 0 || m.Height <= 0 || m.Scale < 0 {
+			return fmt.Errorf("显示器 %s 的分辨率或缩放比例无效", m.Name)
+		}
+	}
+	return nil
+}
+
+// LoadLayout 从 JSON 配置文件读取显示器布局，格式为 {"monitors": [{"name": "DP-1", "x": 0, "y": 0, "width": 2560, "height": 1440}]}
+// 未指定 index 时按文件中的顺序编号
+func LoadLayout(path string) (*Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取显示器布局失败: %v", err)
+	}
+	layout := &Layout{}
+	if err := json.Unmarshal(data, layout); err != nil {
+		return nil, fmt.Errorf("解析显示器布局 %s 失败: %v", path, err)
+	}
+
+	indexed := false
+	for _, m := range layout.Monitors {
+		indexed = indexed || m.Index != 0
+	}
+	if !indexed {
+		for i := range layout.Monitors {
+			layout.Monitors[i].Index = i
+		}
+	}
+	return layout, layout.Validate()
+}
+
+// SetMonitors 使用设置方式为每台显示器设置壁纸，images 以显示器名称为键，必须包含布局中的每台显示器
+func SetMonitors(s Setter, layout *Layout, images map[string]Image) error {
+	for _, m := range layout.Monitors {
+		if _, ok := images[m.Name]; !ok {
+			return fmt.Errorf("缺少显示器 %s 的壁纸", m.Name)
+		}
+	}
+	ms, ok := s.(MonitorSetter)
+	if !ok {
+		return fmt.Errorf("%s %w", s.Name(), ErrUnsupported)
+	}
+	return ms.SetMonitors(layout, images)
+}
+
+// SetSpanned 将一幅画面跨越所有显示器显示
+// 优先为每台显示器设置切分好的图片 images，这样可以正确处理显示器之间的间隙和缩放；
+// 设置方式不支持时，改为让桌面将整幅画布 canvas 跨屏显示
+func SetSpanned(s Setter, layout *Layout, canvas Image, images map[string]Image) error {
+	err := SetMonitors(s, layout, images)
+	if !errors.Is(err, ErrUnsupported) {
+		return err
+	}
+	ss, ok := s.(SpanSetter)
+	if !ok {
+		return err
+	}
+	return ss.SetSpanned(canvas)
+}
+
+// byIndex 返回按序号排列的显示器
+func (l *Layout) byIndex() []Monitor {
+	monitors := append([]Monitor(nil), l.Monitors...)
+	sort.SliceStable(monitors, func(i, j int) bool {
+		return monitors[i].Index < monitors[j].Index
+	})
+	return monitors
+}
+
+// DetectLayout 读取当前的显示器布局：Wayland 会话中使用 wlr-randr，否则使用 xrandr
+func DetectLayout() (*Layout, error) {
+	if os.Getenv("WAYLAND_DISPLAY") != "" && commandExists("wlr-randr") {
+		out, err := output("wlr-randr")
+		if err != nil {
+			return nil, err
+		}
+		return ParseWlrRandr(out)
+	}
+	if commandExists("xrandr") {
+		out, err := output("xrandr", "--listmonitors")
+		if err != nil {
+			return nil, err
+		}
+		return ParseXrandr(out)
+	}
+	return nil, fmt.Errorf("无法检测显示器布局: 需要 wlr-randr 或 xrandr，也可以使用布局配置文件")
+}
+
+var (
+	// xrandr --listmonitors 的输出，如 " 0: +*HDMI-1 1920/527x1080/296+0+0  HDMI-1"
+	xrandrMonitorPattern = regexp.MustCompile(`^\s*(\d+):\s+\+?(\*?)(\S+)\s+(\d+)/\d+x(\d+)/\d+([+-]\d+)([+-]\d+)(?:\s+(\S+))?`)
+	// xrandr --query 的输出，如 "HDMI-1 connected primary 1920x1080+0+0 (normal left inverted right x axis y axis) 527mm x 296mm"
+	// 坐标以 "+%d" 输出，位于原点左侧或上方时为 "1920x1080+-1920+0"
+	xrandrOutputPattern = regexp.MustCompile(`^(\S+) connected (primary )?(\d+)x(\d+)(\+-?\d+|-\d+)(\+-?\d+|-\d+)`)
+)
+
+// ParseXrandr 解析 xrandr --listmonitors 或 xrandr --query 的输出
+func ParseXrandr(text string) (*Layout, error) {
+	layout := &Layout{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := xrandrMonitorPattern.FindStringSubmatch(line); match != nil {
+			name := match[3]
+			if match[8] != "" {
+				name = match[8]
+			}
+			layout.Monitors = append(layout.Monitors, Monitor{
+				Name:    name,
+				Index:   atoi(match[1]),
+				X:       atoi(match[6]),
+				Y:       atoi(match[7]),
+				Width:   atoi(match[4]),
+				Height:  atoi(match[5]),
+				Primary: match[2] == "*",
+			})
+			continue
+		}
+		if match := xrandrOutputPattern.FindStringSubmatch(line); match != nil {
+			layout.Monitors = append(layout.Monitors, Monitor{
+				Name:    match[1],
+				Index:   len(layout.Monitors),
+				X:       atoi(match[5]),
+				Y:       atoi(match[6]),
+				Width:   atoi(match[3]),
+				Height:  atoi(match[4]),
+				Primary: match[2] != "",
+			})
+		}
+	}
+	return layout, layout.Validate()
+}
+
+// wlr-randr 中当前模式的分辨率，如 "    1920x1080 px, 60.000000 Hz (preferred, current)"
+var wlrModePattern = regexp.MustCompile(`^\s+(\d+)x(\d+) px.*current`)
+
+// ParseWlrRandr 解析 wlr-randr 的输出，跳过未启用的输出
+func ParseWlrRandr(text string) (*Layout, error) {
+	layout := &Layout{}
+	var current *Monitor
+	enabled := true
+	transform := "normal"
+
+	flush := func() {
+		if current == nil || !enabled {
+			return
+		}
+		if strings.HasSuffix(transform, "90") || strings.HasSuffix(transform, "270") {
+			current.Width, current.Height = current.Height, current.Width
+		}
+		current.Index = len(layout.Monitors)
+		layout.Monitors = append(layout.Monitors, *current)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			flush()
+			current = &Monitor{Name: strings.Fields(line)[0], Scale: 1}
+			enabled, transform = true, "normal"
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if match := wlrModePattern.FindStringSubmatch(line); match != nil {
+			current.Width, current.Height = atoi(match[1]), atoi(match[2])
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Enabled":
+			enabled = value == "yes"
+		case "Position":
+			x, y, _ := strings.Cut(value, ",")
+			current.X, current.Y = atoi(x), atoi(y)
+		case "Scale":
+			if scale, err := strconv.ParseFloat(value, 64); err == nil && scale > 0 {
+				current.Scale = scale
+			}
+		case "Transform":
+			transform = value
+		}
+	}
+	flush()
+	return layout, layout.Validate()
+}
+
+// atoi 解析整数，忽略前导的 + 号，失败时返回 0
+func atoi(value string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(value), "+"))
+	return n
+}
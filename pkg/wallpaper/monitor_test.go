@@ -0,0 +1,248 @@
+package wallpaper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseXrandr(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Monitor
+	}{
+		{
+			name: "listmonitors",
+			text: `Monitors: 2
+ 0: +*DP-1 2560/597x1440/336+0+0  DP-1
+ 1: +HDMI-1 1920/527x1080/296+2560+180  HDMI-1
+`,
+			want: []Monitor{
+				{Name: "DP-1", Index: 0, X: 0, Y: 0, Width: 2560, Height: 1440, Primary: true},
+				{Name: "HDMI-1", Index: 1, X: 2560, Y: 180, Width: 1920, Height: 1080},
+			},
+		},
+		{
+			name: "listmonitors 旋转的显示器",
+			text: `Monitors: 2
+ 0: +*eDP-1 1920/344x1080/193+1080+420  eDP-1
+ 1: +HDMI-1 1080/296x1920/527+0+0  HDMI-1
+`,
+			want: []Monitor{
+				{Name: "eDP-1", Index: 0, X: 1080, Y: 420, Width: 1920, Height: 1080, Primary: true},
+				{Name: "HDMI-1", Index: 1, X: 0, Y: 0, Width: 1080, Height: 1920},
+			},
+		},
+		{
+			name: "listmonitors 位于原点左侧",
+			text: `Monitors: 2
+ 0: +*eDP-1 1920/344x1080/193+0+0  eDP-1
+ 1: +DP-2 1920/527x1080/296-1920+0  DP-2
+`,
+			want: []Monitor{
+				{Name: "eDP-1", Index: 0, X: 0, Y: 0, Width: 1920, Height: 1080, Primary: true},
+				{Name: "DP-2", Index: 1, X: -1920, Y: 0, Width: 1920, Height: 1080},
+			},
+		},
+		{
+			name: "listmonitors 没有主显示器",
+			text: `Monitors: 1
+ 0: +VGA-1 1280/338x1024/270+0+0  VGA-1
+`,
+			want: []Monitor{
+				{Name: "VGA-1", Index: 0, Width: 1280, Height: 1024},
+			},
+		},
+		{
+			name: "query 跳过未连接和未启用的输出",
+			text: `Screen 0: minimum 320 x 200, current 3000 x 1920, maximum 16384 x 16384
+eDP-1 connected primary 1920x1080+1080+420 (normal left inverted right x axis y axis) 344mm x 193mm
+   1920x1080     60.02*+  60.01    59.97
+   1680x1050     59.95    59.88
+HDMI-1 connected 1080x1920+0+0 left (normal left inverted right x axis y axis) 527mm x 296mm
+   1920x1080     60.00*+  50.00    59.94
+DP-1 disconnected (normal left inverted right x axis y axis)
+DP-2 connected (normal left inverted right x axis y axis)
+   2560x1440     59.95 +
+`,
+			want: []Monitor{
+				{Name: "eDP-1", Index: 0, X: 1080, Y: 420, Width: 1920, Height: 1080, Primary: true},
+				{Name: "HDMI-1", Index: 1, X: 0, Y: 0, Width: 1080, Height: 1920},
+			},
+		},
+		{
+			name: "query 缩放的输出报告缩放后的大小",
+			text: `Screen 0: minimum 320 x 200, current 5760 x 2160, maximum 16384 x 16384
+DP-1 connected primary 3840x2160+0+0 (normal left inverted right x axis y axis) 597mm x 336mm
+   1920x1080     60.00*+
+HDMI-1 connected 1920x1080+3840+0 (normal left inverted right x axis y axis) 527mm x 296mm
+   1920x1080     60.00*+
+`,
+			want: []Monitor{
+				{Name: "DP-1", Index: 0, X: 0, Y: 0, Width: 3840, Height: 2160, Primary: true},
+				{Name: "HDMI-1", Index: 1, X: 3840, Y: 0, Width: 1920, Height: 1080},
+			},
+		},
+		{
+			name: "query 位于原点左侧",
+			text: `Screen 0: minimum 320 x 200, current 3840 x 1080, maximum 16384 x 16384
+eDP-1 connected primary 1920x1080+0+0 (normal left inverted right x axis y axis) 344mm x 193mm
+   1920x1080     60.02*+
+DP-2 connected 1920x1080+-1920+0 (normal left inverted right x axis y axis) 527mm x 296mm
+   1920x1080     60.00*+
+`,
+			want: []Monitor{
+				{Name: "eDP-1", Index: 0, X: 0, Y: 0, Width: 1920, Height: 1080, Primary: true},
+				{Name: "DP-2", Index: 1, X: -1920, Y: 0, Width: 1920, Height: 1080},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := ParseXrandr(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(layout.Monitors, tt.want) {
+				t.Errorf("ParseXrandr()\n got: %+v\nwant: %+v", layout.Monitors, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXrandrEmpty(t *testing.T) {
+	for _, text := range []string{"", "Monitors: 0\n", "DP-1 disconnected (normal left inverted right x axis y axis)\n"} {
+		if _, err := ParseXrandr(text); err == nil {
+			t.Errorf("ParseXrandr(%q) 应返回错误", text)
+		}
+	}
+}
+
+func TestParseWlrRandr(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Monitor
+	}{
+		{
+			name: "缩放、旋转和未启用的输出",
+			text: `eDP-1 "Sharp Corporation 0x14F9 (eDP-1)"
+  Make: Sharp Corporation
+  Model: 0x14F9
+  Serial: (null)
+  Physical size: 290x180 mm
+  Enabled: yes
+  Modes:
+    2560x1600 px, 60.002998 Hz (preferred, current)
+    1920x1200 px, 60.002998 Hz
+  Position: 0,0
+  Transform: normal
+  Scale: 1.500000
+  Adaptive Sync: disabled
+HDMI-A-1 "Dell Inc. DELL U2719D 7XXXXX2 (HDMI-A-1)"
+  Make: Dell Inc.
+  Model: DELL U2719D
+  Serial: 7XXXXX2
+  Physical size: 600x340 mm
+  Enabled: yes
+  Modes:
+    2560x1440 px, 59.951000 Hz (preferred, current)
+    1920x1080 px, 60.000000 Hz
+  Position: 1707,0
+  Transform: 90
+  Scale: 1.000000
+  Adaptive Sync: disabled
+DP-2 "LG Electronics LG HDR 4K 0x0000XXXX (DP-2)"
+  Make: LG Electronics
+  Model: LG HDR 4K
+  Serial: 0x0000XXXX
+  Physical size: 600x340 mm
+  Enabled: no
+  Modes:
+    3840x2160 px, 60.000000 Hz (preferred)
+    2560x1440 px, 59.951000 Hz
+`,
+			want: []Monitor{
+				{Name: "eDP-1", Index: 0, X: 0, Y: 0, Width: 2560, Height: 1600, Scale: 1.5},
+				{Name: "HDMI-A-1", Index: 1, X: 1707, Y: 0, Width: 1440, Height: 2560, Scale: 1},
+			},
+		},
+		{
+			name: "翻转并旋转 270 度",
+			text: `DP-1 "Unknown (DP-1)"
+  Enabled: yes
+  Modes:
+    1920x1080 px, 60.000000 Hz (preferred, current)
+  Position: 0,0
+  Transform: flipped-270
+  Scale: 2.000000
+`,
+			want: []Monitor{
+				{Name: "DP-1", Index: 0, X: 0, Y: 0, Width: 1080, Height: 1920, Scale: 2},
+			},
+		},
+		{
+			name: "当前模式不是首选模式",
+			text: `HDMI-A-1 "Unknown (HDMI-A-1)"
+  Enabled: yes
+  Modes:
+    3840x2160 px, 60.000000 Hz (preferred)
+    1920x1080 px, 60.000000 Hz (current)
+  Position: 0,0
+  Transform: 180
+  Scale: 1.000000
+`,
+			want: []Monitor{
+				{Name: "HDMI-A-1", Index: 0, X: 0, Y: 0, Width: 1920, Height: 1080, Scale: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := ParseWlrRandr(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(layout.Monitors, tt.want) {
+				t.Errorf("ParseWlrRandr()\n got: %+v\nwant: %+v", layout.Monitors, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWlrRandrAllDisabled(t *testing.T) {
+	text := `DP-1 "Unknown (DP-1)"
+  Enabled: no
+  Modes:
+    1920x1080 px, 60.000000 Hz (preferred)
+`
+	if _, err := ParseWlrRandr(text); err == nil {
+		t.Error("没有启用的输出时应返回错误")
+	}
+}
+
+func TestMonitorLogicalBounds(t *testing.T) {
+	layout, err := ParseWlrRandr(`eDP-1 "Unknown (eDP-1)"
+  Enabled: yes
+  Modes:
+    2560x1600 px, 60.002998 Hz (preferred, current)
+  Position: 0,0
+  Transform: normal
+  Scale: 1.500000
+HDMI-A-1 "Unknown (HDMI-A-1)"
+  Enabled: yes
+  Modes:
+    2560x1440 px, 59.951000 Hz (preferred, current)
+  Position: 1707,0
+  Transform: 90
+  Scale: 1.000000
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := layout.Bounds().String(), "(0,0)-(3147,2560)"; got != want {
+		t.Errorf("Bounds() = %s, want %s", got, want)
+	}
+}
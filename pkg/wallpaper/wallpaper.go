@@ -0,0 +1,186 @@
+// Package wallpaper 设置 Linux 桌面壁纸，支持 GNOME、Cinnamon、MATE、KDE Plasma、XFCE、
+// sway、Hyprland，以及 feh、nitrogen 等通用工具
+package wallpaper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandTimeout 是执行外部命令的超时时间
+const CommandTimeout = 10 * time.Second
+
+// Image 是要设置的壁纸
+type Image struct {
+	Path     string // 壁纸路径
+	DarkPath string // 深色模式下使用的壁纸，为空时与 Path 相同；不支持深色模式的桌面会忽略
+}
+
+// Setter 是设置桌面壁纸的方式
+type Setter interface {
+	// Name 返回设置方式的名称，如 gnome、kde、feh
+	Name() string
+	// Available 判断所需的命令是否可用
+	Available() bool
+	// Set 设置壁纸，路径必须为绝对路径
+	Set(img Image) error
+}
+
+// MonitorSetter 是可以为每台显示器设置不同壁纸的设置方式
+type MonitorSetter interface {
+	Setter
+	// SetMonitors 为布局中的每台显示器设置壁纸，images 以显示器名称为键；
+	// 设置方式在当前配置下不支持时返回 ErrUnsupported
+	SetMonitors(layout *Layout, images map[string]Image) error
+}
+
+// SpanSetter 是可以将一张壁纸跨越所有显示器显示的设置方式
+type SpanSetter interface {
+	Setter
+	// SetSpanned 将壁纸跨越所有显示器显示，设置方式在当前配置下不支持时返回 ErrUnsupported
+	SetSpanned(img Image) error
+}
+
+// ErrUnsupported 表示设置方式不支持多显示器壁纸
+var ErrUnsupported = errors.New("不支持多显示器壁纸")
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func() Setter)
+)
+
+// Register 注册一种设置方式，同名的设置方式会被替换
+func Register(name string, factory func() Setter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// New 按名称创建设置方式
+func New(name string) (Setter, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的壁纸设置方式: %s (可选 %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(), nil
+}
+
+// Names 返回所有已注册的设置方式名称，按字母排序
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("gnome", func() Setter { return NewGNOMESetter() })
+	Register("cinnamon", func() Setter { return NewCinnamonSetter() })
+	Register("mate", func() Setter { return NewMATESetter() })
+	Register("kde", func() Setter { return NewKDESetter() })
+	Register("xfce", func() Setter { return NewXFCESetter() })
+	Register("sway", func() Setter { return NewSwaySetter() })
+	Register("hyprland", func() Setter { return NewHyprlandSetter() })
+	for _, setter := range fallbackSetters() {
+		setter := setter
+		Register(setter.Name(), func() Setter { return setter })
+	}
+}
+
+// Resolve 将壁纸路径转换为绝对路径，并解析符号链接（如 latest.jpg）
+// 解析后的路径在每次更新时都不同，桌面环境据此判断壁纸已经变化
+func (img Image) Resolve() (Image, error) {
+	if img.Path == "" {
+		return img, fmt.Errorf("未指定壁纸路径")
+	}
+	path, err := resolvePath(img.Path)
+	if err != nil {
+		return img, err
+	}
+	resolved := Image{Path: path}
+	if img.DarkPath != "" {
+		if resolved.DarkPath, err = resolvePath(img.DarkPath); err != nil {
+			return img, err
+		}
+	}
+	return resolved, nil
+}
+
+// resolvePath 返回解析符号链接后的绝对路径
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("无法获取绝对路径: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("壁纸文件不存在: %s", path)
+	}
+	return resolved, nil
+}
+
+// dark 返回深色模式下使用的壁纸
+func (img Image) dark() string {
+	if img.DarkPath != "" {
+		return img.DarkPath
+	}
+	return img.Path
+}
+
+// fileURI 将绝对路径转换为 file:// URI
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// commandExists 判断命令是否在 PATH 中
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// run 执行外部命令，失败时错误中包含命令的输出
+func run(name string, args ...string) error {
+	_, err := output(name, args...)
+	return err
+}
+
+// output 执行外部命令并返回其标准输出
+func output(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s 执行超时", name)
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(stdout.String())
+		}
+		if message != "" {
+			return "", fmt.Errorf("%s 执行失败: %v: %s", name, err, message)
+		}
+		return "", fmt.Errorf("%s 执行失败: %v", name, err)
+	}
+	return stdout.String(), nil
+}
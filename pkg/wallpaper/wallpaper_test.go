@@ -0,0 +1,144 @@
+package wallpaper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeCommands 在临时目录中创建假命令，并将该目录设为唯一的 PATH
+// scripts 以命令名为键，值为记录参数之后执行的 shell 脚本片段（可为空）
+// 返回的函数按调用顺序读取全部调用记录，每条记录的第一项为命令名
+func fakeCommands(t *testing.T, scripts map[string]string) func() [][]string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("假命令依赖 /bin/sh")
+	}
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	for name, body := range scripts {
+		// 参数以 \037 分隔、调用以 \036 结束，参数中可以包含换行（如 Plasma 脚本）
+		script := "#!/bin/sh\n" +
+			"{ printf '%s' \"${0##*/}\"; for a in \"$@\"; do printf '\\037%s' \"$a\"; done; printf '\\036'; } >> '" + logPath + "'\n" +
+			body + "\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	return func() [][]string {
+		t.Helper()
+		data, err := os.ReadFile(logPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var calls [][]string
+		for _, record := range strings.Split(string(data), "\x1e") {
+			if record != "" {
+				calls = append(calls, strings.Split(record, "\x1f"))
+			}
+		}
+		return calls
+	}
+}
+
+// assertCalls 比较命令调用记录
+func assertCalls(t *testing.T, got, want [][]string) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("调用记录不一致\n got: %q\nwant: %q", got, want)
+	}
+}
+
+// testLayout 是两台显示器的布局：左侧为主显示器 DP-1，右侧为 HDMI-1
+var testLayout = &Layout{Monitors: []Monitor{
+	{Name: "HDMI-1", Index: 1, X: 2560, Y: 0, Width: 1920, Height: 1080},
+	{Name: "DP-1", Index: 0, X: 0, Y: 0, Width: 2560, Height: 1440, Primary: true},
+}}
+
+// testImages 是 testLayout 中每台显示器的壁纸
+var testImages = map[string]Image{
+	"DP-1":   {Path: "/walls/left.jpg"},
+	"HDMI-1": {Path: "/walls/right.jpg"},
+}
+
+func TestCommandSetter(t *testing.T) {
+	setters := make(map[string]*CommandSetter)
+	for _, s := range fallbackSetters() {
+		setters[s.Name()] = s
+	}
+
+	tests := []struct {
+		name  string
+		apply func() error
+		want  [][]string
+	}{
+		{
+			name:  "feh 单张",
+			apply: func() error { return setters["feh"].Set(Image{Path: "/walls/a b.jpg"}) },
+			want:  [][]string{{"feh", "--bg-fill", "/walls/a b.jpg"}},
+		},
+		{
+			name:  "feh 按序号一次设置所有显示器",
+			apply: func() error { return setters["feh"].SetMonitors(testLayout, testImages) },
+			want:  [][]string{{"feh", "--bg-fill", "/walls/left.jpg", "/walls/right.jpg"}},
+		},
+		{
+			name:  "feh 跨屏",
+			apply: func() error { return setters["feh"].SetSpanned(Image{Path: "/walls/wide.jpg"}) },
+			want:  [][]string{{"feh", "--bg-fill", "--no-xinerama", "/walls/wide.jpg"}},
+		},
+		{
+			name:  "nitrogen 每台显示器执行一次",
+			apply: func() error { return setters["nitrogen"].SetMonitors(testLayout, testImages) },
+			want: [][]string{
+				{"nitrogen", "--head=0", "--set-zoom-fill", "--save", "/walls/left.jpg"},
+				{"nitrogen", "--head=1", "--set-zoom-fill", "--save", "/walls/right.jpg"},
+			},
+		},
+		{
+			name:  "xwallpaper 按输出名称",
+			apply: func() error { return setters["xwallpaper"].SetMonitors(testLayout, testImages) },
+			want:  [][]string{{"xwallpaper", "--output", "DP-1", "--zoom", "/walls/left.jpg", "--output", "HDMI-1", "--zoom", "/walls/right.jpg"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeCommands(t, map[string]string{"feh": "", "nitrogen": "", "xwallpaper": ""})
+			if err := tt.apply(); err != nil {
+				t.Fatal(err)
+			}
+			assertCalls(t, calls(), tt.want)
+		})
+	}
+}
+
+func TestCommandSetterFailure(t *testing.T) {
+	fakeCommands(t, map[string]string{"feh": "echo 'feh: No image' >&2; exit 2"})
+
+	err := NewCommandSetter("feh", "feh", "--bg-fill", "{path}").Set(Image{Path: "/walls/a.jpg"})
+	if err == nil || !strings.Contains(err.Error(), "feh: No image") {
+		t.Errorf("错误中应包含命令的输出，实际为 %v", err)
+	}
+}
+
+func TestCommandSetterUnsupported(t *testing.T) {
+	fakeCommands(t, map[string]string{"hsetroot": ""})
+
+	setter, err := New("hsetroot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setter.(MonitorSetter).SetMonitors(testLayout, testImages); err == nil {
+		t.Error("hsetroot 不支持多显示器，应返回错误")
+	}
+}
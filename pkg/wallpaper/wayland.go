@@ -0,0 +1,96 @@
+package wallpaper
+
+import "strings"
+
+// SwaySetter 通过 sway 的 IPC (swaymsg) 设置壁纸，由 sway 调用 swaybg 绘制
+type SwaySetter struct {
+	Mode string // 缩放方式，如 fill、fit、stretch、center、tile
+}
+
+// NewSwaySetter 创建 sway 壁纸设置方式
+func NewSwaySetter() *SwaySetter {
+	return &SwaySetter{Mode: "fill"}
+}
+
+// Name 返回设置方式的名称
+func (s *SwaySetter) Name() string {
+	return "sway"
+}
+
+// Available 判断 swaymsg 是否可用
+func (s *SwaySetter) Available() bool {
+	return commandExists("swaymsg")
+}
+
+// Set 为所有输出设置壁纸
+func (s *SwaySetter) Set(img Image) error {
+	return run("swaymsg", "output", "*", "bg", swayQuote(img.Path), s.Mode)
+}
+
+// SetMonitors 为每个输出分别设置壁纸
+func (s *SwaySetter) SetMonitors(layout *Layout, images map[string]Image) error {
+	for _, m := range layout.Monitors {
+		if err := run("swaymsg", "output", swayQuote(m.Name), "bg", swayQuote(images[m.Name].Path), s.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// swayQuote 为参数加引号；swaymsg 将参数拼接为一条 sway 命令，路径中可能包含空格
+func swayQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// HyprlandSetter 通过 hyprctl 调用 hyprpaper 的 IPC 设置 Hyprland 的壁纸，需要 hyprpaper 正在运行
+type HyprlandSetter struct{}
+
+// NewHyprlandSetter 创建 Hyprland 壁纸设置方式
+func NewHyprlandSetter() *HyprlandSetter {
+	return &HyprlandSetter{}
+}
+
+// Name 返回设置方式的名称
+func (s *HyprlandSetter) Name() string {
+	return "hyprland"
+}
+
+// Available 判断 hyprctl 是否可用
+func (s *HyprlandSetter) Available() bool {
+	return commandExists("hyprctl")
+}
+
+// Set 预加载壁纸并应用到所有显示器，然后释放不再使用的壁纸
+func (s *HyprlandSetter) Set(img Image) error {
+	if err := run("hyprctl", "hyprpaper", "preload", img.Path); err != nil {
+		return err
+	}
+	if err := run("hyprctl", "hyprpaper", "wallpaper", ","+img.Path); err != nil {
+		return err
+	}
+	// 释放失败不影响壁纸的设置
+	run("hyprctl", "hyprpaper", "unload", "unused")
+	return nil
+}
+
+// SetMonitors 预加载每台显示器的壁纸并分别应用，然后释放不再使用的壁纸
+func (s *HyprlandSetter) SetMonitors(layout *Layout, images map[string]Image) error {
+	preloaded := make(map[string]bool)
+	for _, m := range layout.Monitors {
+		path := images[m.Name].Path
+		if preloaded[path] {
+			continue
+		}
+		if err := run("hyprctl", "hyprpaper", "preload", path); err != nil {
+			return err
+		}
+		preloaded[path] = true
+	}
+	for _, m := range layout.Monitors {
+		if err := run("hyprctl", "hyprpaper", "wallpaper", m.Name+","+images[m.Name].Path); err != nil {
+			return err
+		}
+	}
+	run("hyprctl", "hyprpaper", "unload", "unused")
+	return nil
+}
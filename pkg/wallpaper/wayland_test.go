@@ -0,0 +1,115 @@
+package wallpaper
+
+import "testing"
+
+func TestSwaySetter(t *testing.T) {
+	tests := []struct {
+		name  string
+		apply func(s *SwaySetter) error
+		want  [][]string
+	}{
+		{
+			name:  "所有输出",
+			apply: func(s *SwaySetter) error { return s.Set(Image{Path: `/walls/a "b".jpg`}) },
+			want:  [][]string{{"swaymsg", "output", "*", "bg", `"/walls/a \"b\".jpg"`, "fill"}},
+		},
+		{
+			name: "每个输出",
+			apply: func(s *SwaySetter) error {
+				s.Mode = "fit"
+				return s.SetMonitors(testLayout, testImages)
+			},
+			want: [][]string{
+				{"swaymsg", "output", `"HDMI-1"`, "bg", `"/walls/right.jpg"`, "fit"},
+				{"swaymsg", "output", `"DP-1"`, "bg", `"/walls/left.jpg"`, "fit"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeCommands(t, map[string]string{"swaymsg": ""})
+			if err := tt.apply(NewSwaySetter()); err != nil {
+				t.Fatal(err)
+			}
+			assertCalls(t, calls(), tt.want)
+		})
+	}
+}
+
+func TestHyprlandSetter(t *testing.T) {
+	sameImage := map[string]Image{
+		"DP-1":   {Path: "/walls/a.jpg"},
+		"HDMI-1": {Path: "/walls/a.jpg"},
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		apply  func(s *HyprlandSetter) error
+		want   [][]string
+	}{
+		{
+			name:   "所有显示器",
+			script: "",
+			apply:  func(s *HyprlandSetter) error { return s.Set(Image{Path: "/walls/a.jpg"}) },
+			want: [][]string{
+				{"hyprctl", "hyprpaper", "preload", "/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "wallpaper", ",/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "unload", "unused"},
+			},
+		},
+		{
+			name:   "释放失败不影响设置",
+			script: `[ "$2" = unload ] && exit 1; exit 0`,
+			apply:  func(s *HyprlandSetter) error { return s.Set(Image{Path: "/walls/a.jpg"}) },
+			want: [][]string{
+				{"hyprctl", "hyprpaper", "preload", "/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "wallpaper", ",/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "unload", "unused"},
+			},
+		},
+		{
+			name:   "每台显示器",
+			script: "",
+			apply:  func(s *HyprlandSetter) error { return s.SetMonitors(testLayout, testImages) },
+			want: [][]string{
+				{"hyprctl", "hyprpaper", "preload", "/walls/right.jpg"},
+				{"hyprctl", "hyprpaper", "preload", "/walls/left.jpg"},
+				{"hyprctl", "hyprpaper", "wallpaper", "HDMI-1,/walls/right.jpg"},
+				{"hyprctl", "hyprpaper", "wallpaper", "DP-1,/walls/left.jpg"},
+				{"hyprctl", "hyprpaper", "unload", "unused"},
+			},
+		},
+		{
+			name:   "相同的壁纸只预加载一次",
+			script: "",
+			apply:  func(s *HyprlandSetter) error { return s.SetMonitors(testLayout, sameImage) },
+			want: [][]string{
+				{"hyprctl", "hyprpaper", "preload", "/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "wallpaper", "HDMI-1,/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "wallpaper", "DP-1,/walls/a.jpg"},
+				{"hyprctl", "hyprpaper", "unload", "unused"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeCommands(t, map[string]string{"hyprctl": tt.script})
+			if err := tt.apply(NewHyprlandSetter()); err != nil {
+				t.Fatal(err)
+			}
+			assertCalls(t, calls(), tt.want)
+		})
+	}
+}
+
+func TestHyprlandSetterPreloadFailure(t *testing.T) {
+	calls := fakeCommands(t, map[string]string{"hyprctl": `echo "Couldn't connect to hyprpaper" >&2; exit 1`})
+
+	if err := NewHyprlandSetter().Set(Image{Path: "/walls/a.jpg"}); err == nil {
+		t.Fatal("hyprpaper 未运行时应返回错误")
+	}
+	assertCalls(t, calls(), [][]string{{"hyprctl", "hyprpaper", "preload", "/walls/a.jpg"}})
+}
@@ -0,0 +1,81 @@
+package wallpaper
+
+import "strings"
+
+// XFCE 没有已知属性时使用的默认属性
+const xfceDefaultProperty = "/backdrop/screen0/monitor0/workspace0/last-image"
+
+// XFCESetter 通过 xfconf-query 设置 XFCE 的壁纸
+// XFCE 为每个显示器和工作区分别记录壁纸，因此会更新所有已有的 last-image 属性
+type XFCESetter struct{}
+
+// NewXFCESetter 创建 XFCE 壁纸设置方式
+func NewXFCESetter() *XFCESetter {
+	return &XFCESetter{}
+}
+
+// Name 返回设置方式的名称
+func (s *XFCESetter) Name() string {
+	return "xfce"
+}
+
+// Available 判断 xfconf-query 是否可用
+func (s *XFCESetter) Available() bool {
+	return commandExists("xfconf-query")
+}
+
+// Set 为所有显示器和工作区设置壁纸
+func (s *XFCESetter) Set(img Image) error {
+	properties := s.properties()
+	if len(properties) == 0 {
+		return run("xfconf-query", "-c", "xfce4-desktop", "-p", xfceDefaultProperty, "-n", "-t", "string", "-s", img.Path)
+	}
+	for _, property := range properties {
+		if err := run("xfconf-query", "-c", "xfce4-desktop", "-p", property, "-s", img.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMonitors 为每台显示器的所有工作区设置壁纸
+// 属性路径中包含显示器名称，如 /backdrop/screen0/monitorHDMI-1/workspace0/last-image
+func (s *XFCESetter) SetMonitors(layout *Layout, images map[string]Image) error {
+	properties := s.properties()
+	for _, m := range layout.Monitors {
+		path := images[m.Name].Path
+		matched := false
+		for _, property := range properties {
+			if !strings.Contains(property, "/monitor"+m.Name+"/") {
+				continue
+			}
+			matched = true
+			if err := run("xfconf-query", "-c", "xfce4-desktop", "-p", property, "-s", path); err != nil {
+				return err
+			}
+		}
+		if matched {
+			continue
+		}
+		property := "/backdrop/screen0/monitor" + m.Name + "/workspace0/last-image"
+		if err := run("xfconf-query", "-c", "xfce4-desktop", "-p", property, "-n", "-t", "string", "-s", path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// properties 返回 xfce4-desktop 中所有的 last-image 属性
+func (s *XFCESetter) properties() []string {
+	list, err := output("xfconf-query", "-c", "xfce4-desktop", "-l")
+	if err != nil {
+		return nil
+	}
+	var properties []string
+	for _, line := range strings.Split(list, "\n") {
+		if line = strings.TrimSpace(line); strings.HasSuffix(line, "/last-image") {
+			properties = append(properties, line)
+		}
+	}
+	return properties
+}
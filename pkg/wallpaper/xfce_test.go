@@ -0,0 +1,63 @@
+package wallpaper
+
+import "testing"
+
+// xfconf-query -l 列出两台显示器的属性，其中 HDMI-1 没有壁纸属性
+// PATH 中只有假命令，因此只能使用 shell 的内置命令
+const xfconfList = `[ "$3" = -l ] && printf '%s\n' \
+	/backdrop/screen0/monitorDP-1/workspace0/color-style \
+	/backdrop/screen0/monitorDP-1/workspace0/last-image \
+	/backdrop/screen0/monitorDP-1/workspace1/last-image \
+	/backdrop/screen0/monitoreDP-1/workspace0/last-image
+exit 0`
+
+func TestXFCESetter(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		apply  func() error
+		want   [][]string
+	}{
+		{
+			name:   "更新所有已有的壁纸属性",
+			script: xfconfList,
+			apply:  func() error { return NewXFCESetter().Set(Image{Path: "/walls/a b.jpg"}) },
+			want: [][]string{
+				{"xfconf-query", "-c", "xfce4-desktop", "-l"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitorDP-1/workspace0/last-image", "-s", "/walls/a b.jpg"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitorDP-1/workspace1/last-image", "-s", "/walls/a b.jpg"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitoreDP-1/workspace0/last-image", "-s", "/walls/a b.jpg"},
+			},
+		},
+		{
+			name:   "没有壁纸属性时创建默认属性",
+			script: "",
+			apply:  func() error { return NewXFCESetter().Set(Image{Path: "/walls/a.jpg"}) },
+			want: [][]string{
+				{"xfconf-query", "-c", "xfce4-desktop", "-l"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", xfceDefaultProperty, "-n", "-t", "string", "-s", "/walls/a.jpg"},
+			},
+		},
+		{
+			name:   "按显示器名称匹配属性，没有时创建",
+			script: xfconfList,
+			apply:  func() error { return NewXFCESetter().SetMonitors(testLayout, testImages) },
+			want: [][]string{
+				{"xfconf-query", "-c", "xfce4-desktop", "-l"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitorHDMI-1/workspace0/last-image", "-n", "-t", "string", "-s", "/walls/right.jpg"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitorDP-1/workspace0/last-image", "-s", "/walls/left.jpg"},
+				{"xfconf-query", "-c", "xfce4-desktop", "-p", "/backdrop/screen0/monitorDP-1/workspace1/last-image", "-s", "/walls/left.jpg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeCommands(t, map[string]string{"xfconf-query": tt.script})
+			if err := tt.apply(); err != nil {
+				t.Fatal(err)
+			}
+			assertCalls(t, calls(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// runPrune 按保留策略清理壁纸目录，同时删除图片、元数据文件和缩略图
+func runPrune(args []string) int {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	var (
+		inputDir    string
+		keepDays    int
+		maxSize     string
+		keepDaily   int
+		keepWeekly  int
+		keepMonthly int
+		gfs         bool
+		favorites   string
+		dryRun      bool
+		logLevel    string
+		noTime      bool
+	)
+	fs.StringVar(&inputDir, "dir", "./bing_wallpapers", "壁纸目录")
+	fs.IntVar(&keepDays, "keep-days", 0, "保留最近 N 天的全部壁纸")
+	fs.StringVar(&maxSize, "max-size", "", "壁纸目录的总大小上限 (如 500MB、10GB)，超出时从最旧的壁纸开始删除")
+	fs.IntVar(&keepDaily, "keep-daily", 0, "最近 N 天中每天保留一张")
+	fs.IntVar(&keepWeekly, "keep-weekly", 0, "最近 N 周中每周保留一张")
+	fs.IntVar(&keepMonthly, "keep-monthly", 0, "最近 N 个月中每月保留一张")
+	fs.BoolVar(&gfs, "gfs", false, fmt.Sprintf("祖父-父-子轮换，等同于 -keep-daily %d -keep-weekly %d", bingclient.DefaultGFSDaily, bingclient.DefaultGFSWeekly))
+	fs.StringVar(&favorites, "favorites", "", "收藏列表文件，其中的壁纸始终保留，默认为壁纸目录中的 "+bingclient.FavoritesFilename)
+	fs.BoolVar(&dryRun, "dry-run", false, "仅列出将被删除的壁纸，不实际删除")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	if keepDays < 0 || keepDaily < 0 || keepWeekly < 0 || keepMonthly < 0 {
+		fmt.Printf("错误: 保留数量不能为负数\n")
+		return 1
+	}
+
+	policy := &bingclient.RetentionPolicy{
+		KeepDays:    keepDays,
+		KeepDaily:   keepDaily,
+		KeepWeekly:  keepWeekly,
+		KeepMonthly: keepMonthly,
+	}
+	if gfs {
+		if policy.KeepDaily == 0 {
+			policy.KeepDaily = bingclient.DefaultGFSDaily
+		}
+		if policy.KeepWeekly == 0 {
+			policy.KeepWeekly = bingclient.DefaultGFSWeekly
+		}
+	}
+	if maxSize != "" {
+		size, err := bingclient.ParseByteSize(maxSize)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return 1
+		}
+		policy.MaxBytes = size
+	}
+	if policy.Empty() {
+		fmt.Printf("错误: 至少需要指定一条保留策略 (-keep-days, -max-size, -keep-daily, -keep-weekly, -keep-monthly, -gfs)\n")
+		return 1
+	}
+
+	logger := newLogger(logLevel, noTime)
+
+	absInputDir, err := filepath.Abs(inputDir)
+	if err != nil {
+		fmt.Printf("错误: 无法获取绝对路径: %v\n", err)
+		return 1
+	}
+
+	if err := applyRetention(absInputDir, policy, favorites, dryRun, logger); err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// applyRetention 按保留策略清理壁纸目录，favoritesPath 为空时使用目录中的 favorites.txt
+// 仍被别名引用的壁纸会被跳过；dryRun 时只输出计划，不删除文件
+func applyRetention(dir string, policy *bingclient.RetentionPolicy, favoritesPath string, dryRun bool, logger bingclient.Logger) error {
+	if favoritesPath == "" {
+		favoritesPath = filepath.Join(dir, bingclient.FavoritesFilename)
+	}
+	favorites, err := bingclient.LoadFavorites(favoritesPath)
+	if err != nil {
+		return err
+	}
+	policy.Favorites = append(policy.Favorites, favorites...)
+
+	wallpapers, err := bingclient.ScanWallpapers(dir)
+	if err != nil {
+		return err
+	}
+	logger.Info("共找到 %d 张壁纸，%d 个收藏", len(wallpapers), len(policy.Favorites))
+
+	plan := policy.Plan(wallpapers, dir, time.Now())
+	aliases := aliasFiles(dir)
+
+	kept := len(plan.Keep)
+	var deleted, failed int
+	var freed int64
+	for _, candidate := range plan.Delete {
+		w := candidate.Wallpaper
+		if alias := referencingAlias(aliases, w.Path); alias != "" {
+			logger.Warning("跳过 %s: 仍被别名 %s 引用", w.Path, filepath.Base(alias))
+			kept++
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("将删除: %s (%s, %s)\n", relPath(dir, w.Path), candidate.Reason, bingclient.FormatByteSize(candidate.Size))
+			for _, path := range w.RelatedFiles() {
+				fmt.Printf("  %s\n", relPath(dir, path))
+			}
+			deleted++
+			freed += candidate.Size
+			continue
+		}
+
+		if _, err := w.Remove(logger); err != nil {
+			kept++
+			failed++
+			continue
+		}
+		fmt.Printf("已删除: %s (%s)\n", relPath(dir, w.Path), candidate.Reason)
+		deleted++
+		freed += candidate.Size
+	}
+
+	if dryRun {
+		fmt.Printf("\n预览完成: 保留 %d 张，将删除 %d 张，释放 %s\n", kept, deleted, bingclient.FormatByteSize(freed))
+	} else {
+		fmt.Printf("\n清理完成: 保留 %d 张，已删除 %d 张，释放 %s\n", kept, deleted, bingclient.FormatByteSize(freed))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d 张壁纸删除失败", failed)
+	}
+	return nil
+}
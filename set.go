@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+	"github.com/DeyiXu/bingWallpaper/pkg/wallpaper"
+)
+
+// runSet 将壁纸设置为桌面壁纸，默认使用壁纸目录中的 latest.jpg（不存在时使用日期最新的壁纸），并根据桌面环境自动选择设置方式
+// 使用 -monitors 时从壁纸目录中选取最新的壁纸，为每台显示器分别设置
+func runSet(args []string) int {
+	fs := flag.NewFlagSet("set", flag.ExitOnError)
+	var (
+		opts     desktopOptions
+		input    string
+		inputDir string
+		list     bool
+		logLevel string
+		noTime   bool
+	)
+	fs.StringVar(&input, "i", "", "要设置的壁纸，默认为壁纸目录中的 latest.jpg，不存在时使用日期最新的壁纸")
+	fs.StringVar(&inputDir, "dir", "./bing_wallpapers", "壁纸目录")
+	opts.register(fs)
+	fs.StringVar(&opts.dark, "dark", "", "深色模式下使用的壁纸（仅 GNOME），默认与浅色模式相同")
+	fs.BoolVar(&list, "list", false, "列出所有设置方式及其是否可用")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	if list {
+		for _, name := range wallpaper.Names() {
+			s, _ := wallpaper.New(name)
+			status := "不可用"
+			if s.Available() {
+				status = "可用"
+			}
+			fmt.Printf("%-10s %s\n", name, status)
+		}
+		return 0
+	}
+
+	logger := newLogger(logLevel, noTime)
+
+	if err := opts.validate(); err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	if opts.multiMonitor() {
+		if input != "" {
+			fmt.Printf("错误: -i 不能与 -monitors %s 同时使用\n", opts.monitors)
+			return 1
+		}
+		if err := setLocalMonitors(inputDir, &opts, logger); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if input == "" {
+		var err error
+		if input, err = latestWallpaper(inputDir); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return 1
+		}
+	}
+	img, err := wallpaper.Image{Path: input, DarkPath: opts.dark}.Resolve()
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	if err := setWallpaper(img, opts.setter, logger); err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// setLocalMonitors 使用壁纸目录中最新的壁纸为每台显示器设置壁纸
+func setLocalMonitors(dir string, opts *desktopOptions, logger bingclient.Logger) error {
+	layout, err := opts.loadLayout(logger)
+	if err != nil {
+		return err
+	}
+	count := 1
+	if opts.monitors == monitorsPerDay {
+		count = len(layout.Monitors)
+	}
+	results, err := localResults(dir, count)
+	if err != nil {
+		return err
+	}
+	return applyMonitorWallpapers(results, opts, layout, logger)
+}
+
+// setWallpaper 使用指定的设置方式设置壁纸，name 为 auto 时自动检测
+func setWallpaper(img wallpaper.Image, name string, logger bingclient.Logger) error {
+	s, err := resolveSetter(name)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("使用 %s 设置壁纸: %s", s.Name(), img.Path)
+	if err := s.Set(img); err != nil {
+		return fmt.Errorf("设置壁纸失败: %v", err)
+	}
+	logger.Info("壁纸设置成功")
+	return nil
+}
+
+// resolveSetter 按名称创建设置方式并检查是否可用，name 为 auto 时自动检测
+func resolveSetter(name string) (wallpaper.Setter, error) {
+	var s wallpaper.Setter
+	var err error
+	if name == "" || name == "auto" {
+		s, err = wallpaper.Detect()
+	} else {
+		s, err = wallpaper.New(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !s.Available() {
+		return nil, fmt.Errorf("壁纸设置方式 %s 不可用: 缺少所需的命令", s.Name())
+	}
+	return s, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// runTheme 根据壁纸生成终端和编辑器配色方案
+func runTheme(args []string) int {
+	fs := flag.NewFlagSet("theme", flag.ExitOnError)
+	var (
+		input     string
+		outputDir string
+		format    string
+		output    string
+		mode      string
+		colors    int
+	)
+	fs.StringVar(&input, "i", "", "壁纸图片或元数据文件 (.json, .yaml)，默认为 <dir>/latest.jpg，不存在时使用日期最新的壁纸")
+	fs.StringVar(&outputDir, "dir", "./bing_wallpapers", "壁纸保存目录")
+	fs.StringVar(&format, "format", "xresources", "配色方案格式 (xresources, kitty, alacritty, json)")
+	fs.StringVar(&output, "o", "", "输出文件路径，默认输出到标准输出")
+	fs.StringVar(&mode, "mode", "auto", "深色或浅色方案 (auto, dark, light)，auto 根据壁纸明暗决定")
+	fs.IntVar(&colors, "colors", bingclient.DefaultPaletteSize, "从图片中提取的颜色数量")
+	fs.Parse(args)
+
+	themeFormat, err := bingclient.ParseThemeFormat(format)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	if mode != "auto" && mode != "dark" && mode != "light" {
+		fmt.Printf("错误: mode 参数必须为 auto、dark 或 light\n")
+		return 1
+	}
+	if input == "" {
+		var err error
+		if input, err = latestWallpaper(outputDir); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return 1
+		}
+	}
+
+	palette, err := loadPalette(input, colors)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	dark := palette.IsDark()
+	if mode != "auto" {
+		dark = mode == "dark"
+	}
+
+	data, err := bingclient.NewTheme(palette, dark).Export(themeFormat)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	if output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Printf("错误: 写入配色方案失败: %v\n", err)
+		return 1
+	}
+	fmt.Printf("配色方案已保存: %s\n", output)
+	return 0
+}
+
+// loadPalette 从图片中提取调色板，或从元数据文件中读取已保存的调色板
+func loadPalette(path string, colors int) (*bingclient.Palette, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" || ext == ".yaml" || ext == ".yml" {
+		meta, err := bingclient.LoadImageMetadata(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(meta.Palette) == 0 {
+			return nil, fmt.Errorf("元数据文件中没有调色板，请使用 -palette 重新下载或直接指定图片: %s", path)
+		}
+		return bingclient.PaletteFromHex(meta.Palette)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开图片失败: %v", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %v", err)
+	}
+	return bingclient.ExtractPalette(img, colors), nil
+}
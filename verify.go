@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/DeyiXu/bingWallpaper/pkg/bingclient"
+)
+
+// runVerify 校验壁纸目录中的图片，并根据元数据中的下载地址重新下载损坏的图片
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	var (
+		inputDir string
+		repair   bool
+		manifest bool
+		locale   string
+		hd       bool
+		logLevel string
+		noTime   bool
+	)
+	fs.StringVar(&inputDir, "dir", "./bing_wallpapers", "壁纸目录")
+	fs.BoolVar(&repair, "repair", true, "重新下载损坏的图片")
+	fs.StringVar(&locale, "locale", "zh-CN", "没有元数据时按日期重新获取壁纸所用的语言区域，文件名中包含市场代码时以文件名为准")
+	fs.BoolVar(&hd, "hd", true, "没有元数据时重新下载高清壁纸")
+	fs.BoolVar(&manifest, "manifest", false, "按 SHA256SUMS 清单校验目录中的全部文件（仅报告，不修复）")
+	fs.StringVar(&logLevel, "log-level", "info", "日志级别 (debug, info, warning, error)")
+	fs.BoolVar(&noTime, "no-time", false, "日志中不显示时间戳")
+	fs.Parse(args)
+
+	logger := newLogger(logLevel, noTime)
+
+	absInputDir, err := filepath.Abs(inputDir)
+	if err != nil {
+		fmt.Printf("错误: 无法获取绝对路径: %v\n", err)
+		return 1
+	}
+
+	if manifest {
+		return verifyManifests(absInputDir, logger)
+	}
+
+	wallpapers, err := bingclient.ScanWallpapers(absInputDir)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+	logger.Info("共找到 %d 张壁纸，正在校验...", len(wallpapers))
+
+	source := &repairSource{locale: locale, highQuality: hd, logger: logger}
+	storage := bingclient.NewFileStorage(logger)
+
+	var corrupt, repaired int
+	for _, w := range wallpapers {
+		err := bingclient.VerifyWallpaper(w, bingclient.DefaultMinImageWidth, bingclient.DefaultMinImageHeight)
+		if err == nil {
+			logger.Debug("校验通过: %s", w.Path)
+			continue
+		}
+
+		corrupt++
+		fmt.Printf("损坏: %s (%v)\n", relPath(absInputDir, w.Path), err)
+		if !repair {
+			continue
+		}
+		if err := repairWallpaper(source, storage, w); err != nil {
+			fmt.Printf("  修复失败: %v\n", err)
+			continue
+		}
+		repaired++
+		fmt.Printf("  已重新下载\n")
+	}
+
+	fmt.Printf("\n校验完成: 共 %d 张壁纸，%d 张损坏", len(wallpapers), corrupt)
+	if repair {
+		fmt.Printf("，已修复 %d 张", repaired)
+	}
+	fmt.Println()
+
+	if corrupt > repaired {
+		return 1
+	}
+	return 0
+}
+
+// repairSource 为修复损坏的壁纸提供按市场创建的客户端
+type repairSource struct {
+	locale      string                        // 文件名中没有市场代码时使用的语言区域
+	highQuality bool                          // 按日期获取时是否下载高清壁纸
+	logger      bingclient.Logger             // 日志记录器
+	clients     map[string]*bingclient.Client // 按市场缓存的客户端
+}
+
+// client 返回指定市场的客户端
+func (s *repairSource) client(market string) *bingclient.Client {
+	if s.clients == nil {
+		s.clients = make(map[string]*bingclient.Client)
+	}
+	client, ok := s.clients[market]
+	if !ok {
+		client = bingclient.NewClient(
+			bingclient.WithLogger(s.logger),
+			bingclient.WithLocale(market),
+			bingclient.WithHighQuality(s.highQuality),
+		)
+		s.clients[market] = client
+	}
+	return client
+}
+
+// fetch 重新获取壁纸的图片数据
+// 优先使用元数据中记录的下载地址；没有时按文件名中的日期和市场从 Bing 查询，只能找回 Bing 仍保留的最近几天
+func (s *repairSource) fetch(w *bingclient.LocalWallpaper) ([]byte, error) {
+	if w.Metadata != nil && w.Metadata.URL != "" {
+		return s.client(s.locale).FetchImage(w.Metadata.URL)
+	}
+
+	date := w.Date()
+	if date == "" {
+		return nil, fmt.Errorf("没有记录下载地址的元数据，文件名中也没有日期")
+	}
+	market := s.locale
+	if w.Metadata != nil && w.Metadata.Market != "" {
+		market = w.Metadata.Market
+	} else if m, ok := bingclient.MarketFromFilename(w.Path); ok {
+		market = m
+	}
+
+	client := s.client(market)
+	day, err := bingclient.ParseBingDate(date, bingclient.MarketLocation(market))
+	if err != nil {
+		return nil, err
+	}
+	imageData, err := client.FetchImageDataByDate(day)
+	if err != nil {
+		return nil, fmt.Errorf("按日期 %s (%s) 查询壁纸失败: %v", date, market, err)
+	}
+	return client.FetchRawImageData(imageData)
+}
+
+// repairWallpaper 重新下载损坏的图片，并更新元数据中的校验和
+func repairWallpaper(source *repairSource, storage bingclient.Storage, w *bingclient.LocalWallpaper) error {
+	data, err := source.fetch(w)
+	if err != nil {
+		return err
+	}
+	if err := storage.Save(data, w.Path); err != nil {
+		return err
+	}
+
+	if w.Metadata != nil {
+		w.Metadata.DownloadedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	if err := w.RefreshChecksum(storage); err != nil {
+		return err
+	}
+
+	// 同步更新校验和清单
+	for _, path := range []string{w.Path, w.MetadataPath} {
+		if err := bingclient.UpdateManifest(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyManifests 按 SHA256SUMS 清单校验目录中的全部文件
+func verifyManifests(dir string, logger bingclient.Logger) int {
+	problems, checked, err := bingclient.VerifyManifests(dir, logger)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return 1
+	}
+
+	for _, problem := range problems {
+		fmt.Printf("失败: %s (%v)\n", relPath(dir, problem.Path), problem.Err)
+	}
+	fmt.Printf("\n校验完成: 共 %d 个文件，%d 个失败\n", checked, len(problems))
+
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}